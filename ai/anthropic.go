@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicVersion is the Messages API version requests are written for
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens is used when a request sets no limit, since the
+	// Messages API requires one
+	anthropicMaxTokens = 1024
+)
+
+// anthropicProvider talks to the Anthropic Messages API
+type anthropicProvider struct {
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// anthropicRequest is the body of /v1/messages. The system prompt is a
+// field of its own rather than a message.
+type anthropicRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float64   `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+// anthropicResponse is a whole response
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicEvent is one server-sent event of a streamed response. Only
+// text deltas, the end of the message and errors matter here.
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicModels is the response of /v1/models
+type anthropicModels struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+func newAnthropicProvider(baseURL, apiKey string) *anthropicProvider {
+	client, stream := httpClients()
+	return &anthropicProvider{baseURL: baseURL, apiKey: apiKey, httpClient: client, streamClient: stream}
+}
+
+// headers returns the authentication and version headers
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// request converts a chat request to the Messages API format, moving
+// system messages into the system prompt
+func (p *anthropicProvider) request(req CompletionRequest, stream bool) anthropicRequest {
+	out := anthropicRequest{
+		Model:       req.Model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Stream:      stream,
+	}
+	if out.MaxTokens == 0 {
+		out.MaxTokens = anthropicMaxTokens
+	}
+	var system []string
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		out.Messages = append(out.Messages, msg)
+	}
+	out.System = strings.Join(system, "\n\n")
+	return out
+}
+
+// Complete sends the request and returns the text of the answer
+func (p *anthropicProvider) Complete(ctx context.Context, req CompletionRequest) (string, error) {
+	httpResp, err := doJSON(ctx, p.httpClient, "POST", p.baseURL+"/v1/messages", p.headers(), p.request(req, false))
+	if err != nil {
+		return "", err
+	}
+
+	var resp anthropicResponse
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String(), nil
+}
+
+// Stream sends the request and reads the answer from server-sent events
+func (p *anthropicProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (string, error) {
+	ctx, wrap, stop := idleContext(ctx)
+	defer stop()
+
+	httpResp, err := doJSON(ctx, p.streamClient, "POST", p.baseURL+"/v1/messages", p.headers(), p.request(req, true))
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	var content strings.Builder
+	err = readEvents(wrap(httpResp.Body), func(data string) (bool, error) {
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, fmt.Errorf("failed to decode stream event: %w", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				content.WriteString(event.Delta.Text)
+				if onDelta != nil {
+					onDelta(event.Delta.Text)
+				}
+			}
+		case "message_stop":
+			return false, nil
+		case "error":
+			return false, errors.New(event.Error.Message)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return content.String(), nil
+}
+
+// ListModels returns the models available to the API key
+func (p *anthropicProvider) ListModels(ctx context.Context) ([]Model, error) {
+	httpResp, err := doJSON(ctx, p.httpClient, "GET", p.baseURL+"/v1/models", p.headers(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list anthropicModels
+	if err := decodeJSON(httpResp, &list); err != nil {
+		return nil, err
+	}
+	models := make([]Model, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, Model{ID: m.ID, OwnedBy: "anthropic"})
+	}
+	return models, nil
+}
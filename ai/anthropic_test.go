@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// checkAnthropicHeaders checks the authentication and version headers
+func checkAnthropicHeaders(t *testing.T, r *http.Request) {
+	t.Helper()
+	if got := r.Header.Get("x-api-key"); got != "sk-ant-test" {
+		t.Errorf("x-api-key = %q", got)
+	}
+	if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+		t.Errorf("anthropic-version = %q", got)
+	}
+}
+
+func TestAnthropicComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/messages" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		checkAnthropicHeaders(t, r)
+		var req anthropicRequest
+		decodeBody(t, r, &req)
+		wantMessages := []Message{{Role: "user", Content: "hi"}}
+		if req.System != "be brief" || !reflect.DeepEqual(req.Messages, wantMessages) || req.MaxTokens != 50 || req.Stream {
+			t.Errorf("request body = %+v", req)
+		}
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"hel"},{"type":"tool_use"},{"type":"text","text":"lo"}]}`)
+	}))
+	defer srv.Close()
+
+	got, err := newAnthropicProvider(srv.URL, "sk-ant-test").Complete(context.Background(), testRequest)
+	if err != nil || got != "hello" {
+		t.Errorf("Complete = %q, %v; want hello", got, err)
+	}
+}
+
+func TestAnthropicDefaultMaxTokens(t *testing.T) {
+	req := newAnthropicProvider("", "").request(CompletionRequest{Model: "m"}, false)
+	if req.MaxTokens != anthropicMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", req.MaxTokens, anthropicMaxTokens)
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checkAnthropicHeaders(t, r)
+		var req anthropicRequest
+		decodeBody(t, r, &req)
+		if !req.Stream {
+			t.Error("stream was not requested")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{}\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"!\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	onDelta, deltas := collectDeltas()
+	got, err := newAnthropicProvider(srv.URL, "sk-ant-test").Stream(context.Background(), testRequest, onDelta)
+	if err != nil || got != "Hello" {
+		t.Errorf("Stream = %q, %v; want Hello", got, err)
+	}
+	if !reflect.DeepEqual(*deltas, []string{"Hel", "lo"}) {
+		t.Errorf("deltas = %q", *deltas)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	_, err := newAnthropicProvider(srv.URL, "sk-ant-test").Stream(context.Background(), testRequest, nil)
+	if err == nil || err.Error() != "failed to read stream: Overloaded" {
+		t.Errorf("err = %v, want the error event's message", err)
+	}
+}
+
+func TestAnthropicListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1/models" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		checkAnthropicHeaders(t, r)
+		fmt.Fprint(w, `{"data":[{"id":"claude-a","type":"model"},{"id":"claude-b","type":"model"}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := newAnthropicProvider(srv.URL, "sk-ant-test").ListModels(context.Background())
+	want := []Model{{ID: "claude-a", OwnedBy: "anthropic"}, {ID: "claude-b", OwnedBy: "anthropic"}}
+	if err != nil || !reflect.DeepEqual(models, want) {
+		t.Errorf("ListModels = %+v, %v; want %+v", models, err, want)
+	}
+}
@@ -1,32 +1,34 @@
 package ai
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
-	"time"
 )
 
-// Client represents the LiteLLM API client
+// DefaultModel is used when no model is configured
+const DefaultModel = "qwen3-terminal"
+
+// Client generates commands with a model served by a Provider
 type Client struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	provider Provider
+	model    string
 }
 
-// NewClient creates a new LiteLLM API client
-func NewClient(baseURL, apiKey string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// NewClient creates a client for an OpenAI-compatible endpoint such as a
+// LiteLLM proxy, asking for the given model. An empty model uses
+// DefaultModel.
+func NewClient(baseURL, apiKey, model string) *Client {
+	return NewClientWithProvider(newOpenAIProvider(baseURLOrDefault(baseURL, ""), apiKey), model)
+}
+
+// NewClientWithProvider creates a client that asks the provider for the
+// given model. An empty model uses DefaultModel.
+func NewClientWithProvider(provider Provider, model string) *Client {
+	if model == "" {
+		model = DefaultModel
 	}
+	return &Client{provider: provider, model: model}
 }
 
 // CompletionRequest represents an API request
@@ -35,6 +37,7 @@ type CompletionRequest struct {
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float64   `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
 }
 
 // Message represents a chat message
@@ -43,30 +46,58 @@ type Message struct {
 	Content string `json:"content"`
 }
 
-// CompletionResponse represents an API response
-type CompletionResponse struct {
+// Model is a model served by a provider
+type Model struct {
 	ID      string `json:"id"`
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	OwnedBy string `json:"owned_by,omitempty"`
 }
 
 // Context contains terminal context for AI
  type Context struct {
 	OS          string
 	Shell       string
+	// Dialect is the shell's command syntax, e.g. "posix", "fish" or "powershell"
+	Dialect     string
 	WorkingDir  string
 }
 
 // GenerateCommand creates an AI-generated command from natural language
 func (c *Client) GenerateCommand(ctx context.Context, userPrompt string, context Context) (string, error) {
+	req := c.commandRequest(userPrompt, context)
+
+	command, err := c.provider.Complete(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if command == "" {
+		return "", fmt.Errorf("no response from AI")
+	}
+
+	// Clean up the command (remove markdown, extra whitespace)
+	command = cleanCommand(command)
+
+	return command, nil
+}
+
+// GenerateCommandStream is GenerateCommand with the answer streamed:
+// onToken receives each piece of text as it arrives, and the cleaned up
+// command is returned once the answer is complete
+func (c *Client) GenerateCommandStream(ctx context.Context, userPrompt string, context Context, onToken func(string)) (string, error) {
+	req := c.commandRequest(userPrompt, context)
+
+	content, err := c.provider.Stream(ctx, req, onToken)
+	if err != nil {
+		return "", err
+	}
+	if content == "" {
+		return "", fmt.Errorf("no response from AI")
+	}
+
+	return cleanCommand(content), nil
+}
+
+// commandRequest builds the request for a command generation prompt
+func (c *Client) commandRequest(userPrompt string, context Context) CompletionRequest {
 	// Build system prompt with context
 	systemPrompt := fmt.Sprintf(`You are a terminal command generator. 
 Generate the correct command for the user's request.
@@ -74,6 +105,7 @@ Generate the correct command for the user's request.
 Context:
 - OS: %s
 - Shell: %s
+- Shell syntax: %s
 - Current Directory: %s
 
 Rules:
@@ -82,10 +114,10 @@ Rules:
 3. Ensure paths are properly escaped
 4. Use OS-appropriate commands (e.g., 'dir' for Windows CMD, 'ls' for bash)
 
-Generate command:`, context.OS, context.Shell, context.WorkingDir)
+Generate command:`, context.OS, context.Shell, dialectOrDefault(context.Dialect), context.WorkingDir)
 
-	req := CompletionRequest{
-		Model:       "qwen3-terminal",
+	return CompletionRequest{
+		Model:       c.model,
 		MaxTokens:   100,
 		Temperature: 0.1,
 		Messages: []Message{
@@ -93,55 +125,24 @@ Generate command:`, context.OS, context.Shell, context.WorkingDir)
 			{Role: "user", Content: userPrompt},
 		},
 	}
-
-	resp, err := c.sendRequest(ctx, req)
-	if err != nil {
-		return "", err
-	}
-
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from AI")
-	}
-
-	command := resp.Choices[0].Message.Content
-	// Clean up the command (remove markdown, extra whitespace)
-	command = cleanCommand(command)
-
-	return command, nil
 }
 
-// sendRequest sends the API request to LiteLLM
-func (c *Client) sendRequest(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/chat/completions", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer httpResp.Body.Close()
+// Model returns the model the client asks for
+func (c *Client) Model() string {
+	return c.model
+}
 
-	if httpResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(httpResp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", httpResp.StatusCode, string(body))
-	}
+// ListModels returns the models the provider serves
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	return c.provider.ListModels(ctx)
+}
 
-	var resp CompletionResponse
-	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// dialectOrDefault names the shell syntax for the prompt when none is known
+func dialectOrDefault(dialect string) string {
+	if dialect == "" {
+		return "posix"
 	}
-
-	return &resp, nil
+	return dialect
 }
 
 // cleanCommand removes markdown and whitespace from AI output
@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ollamaProvider talks to Ollama's native /api/chat endpoint
+type ollamaProvider struct {
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// ollamaRequest is the body of /api/chat. Ollama streams unless told not
+// to, so Stream is always sent.
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options,omitempty"`
+}
+
+// ollamaOptions are the model parameters of a request
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+// ollamaResponse is a whole response, or one line of a streamed one
+type ollamaResponse struct {
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// ollamaTags is the response of /api/tags, the locally installed models
+type ollamaTags struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+func newOllamaProvider(baseURL, apiKey string) *ollamaProvider {
+	client, stream := httpClients()
+	return &ollamaProvider{baseURL: baseURL, apiKey: apiKey, httpClient: client, streamClient: stream}
+}
+
+// headers returns the authentication headers. Ollama needs none, but a
+// key is passed on for servers behind an authenticating proxy.
+func (p *ollamaProvider) headers() map[string]string {
+	if p.apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.apiKey}
+}
+
+// request converts a chat request to Ollama's format
+func (p *ollamaProvider) request(req CompletionRequest, stream bool) ollamaRequest {
+	return ollamaRequest{
+		Model:    req.Model,
+		Messages: req.Messages,
+		Stream:   stream,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			NumPredict:  req.MaxTokens,
+		},
+	}
+}
+
+// Complete sends the request and returns the answer
+func (p *ollamaProvider) Complete(ctx context.Context, req CompletionRequest) (string, error) {
+	httpResp, err := doJSON(ctx, p.httpClient, "POST", p.baseURL+"/api/chat", p.headers(), p.request(req, false))
+	if err != nil {
+		return "", err
+	}
+
+	var resp ollamaResponse
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Message.Content, nil
+}
+
+// Stream sends the request and reads the answer as newline-delimited JSON
+func (p *ollamaProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (string, error) {
+	ctx, wrap, stop := idleContext(ctx)
+	defer stop()
+
+	httpResp, err := doJSON(ctx, p.streamClient, "POST", p.baseURL+"/api/chat", p.headers(), p.request(req, true))
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	var content strings.Builder
+	err = readLines(wrap(httpResp.Body), func(line string) (bool, error) {
+		if strings.TrimSpace(line) == "" {
+			return true, nil
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return false, errors.New(chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if onDelta != nil {
+				onDelta(chunk.Message.Content)
+			}
+		}
+		return !chunk.Done, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return content.String(), nil
+}
+
+// ListModels returns the models installed on the Ollama server
+func (p *ollamaProvider) ListModels(ctx context.Context) ([]Model, error) {
+	httpResp, err := doJSON(ctx, p.httpClient, "GET", p.baseURL+"/api/tags", p.headers(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags ollamaTags
+	if err := decodeJSON(httpResp, &tags); err != nil {
+		return nil, err
+	}
+	models := make([]Model, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, Model{ID: m.Name, OwnedBy: "ollama"})
+	}
+	return models, nil
+}
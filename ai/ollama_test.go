@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOllamaComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/chat" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none without a key", got)
+		}
+		var req ollamaRequest
+		decodeBody(t, r, &req)
+		if req.Model != "test-model" || req.Stream || req.Options.NumPredict != 50 {
+			t.Errorf("request body = %+v", req)
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"hello"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	got, err := newOllamaProvider(srv.URL, "").Complete(context.Background(), testRequest)
+	if err != nil || got != "hello" {
+		t.Errorf("Complete = %q, %v; want hello", got, err)
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		decodeBody(t, r, &req)
+		if !req.Stream {
+			t.Error("stream was not requested")
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+		fmt.Fprintln(w, ``)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"lo"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"!"},"done":false}`)
+	}))
+	defer srv.Close()
+
+	onDelta, deltas := collectDeltas()
+	got, err := newOllamaProvider(srv.URL, "").Stream(context.Background(), testRequest, onDelta)
+	if err != nil || got != "Hello" {
+		t.Errorf("Stream = %q, %v; want Hello", got, err)
+	}
+	if !reflect.DeepEqual(*deltas, []string{"Hel", "lo"}) {
+		t.Errorf("deltas = %q", *deltas)
+	}
+}
+
+func TestOllamaStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+		fmt.Fprintln(w, `{"error":"model unloaded"}`)
+	}))
+	defer srv.Close()
+
+	_, err := newOllamaProvider(srv.URL, "").Stream(context.Background(), testRequest, nil)
+	if err == nil || err.Error() != "failed to read stream: model unloaded" {
+		t.Errorf("err = %v, want the stream's error", err)
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer proxy-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		fmt.Fprint(w, `{"models":[{"name":"llama3:8b"},{"name":"qwen3:4b"}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := newOllamaProvider(srv.URL, "proxy-key").ListModels(context.Background())
+	want := []Model{{ID: "llama3:8b", OwnedBy: "ollama"}, {ID: "qwen3:4b", OwnedBy: "ollama"}}
+	if err != nil || !reflect.DeepEqual(models, want) {
+		t.Errorf("ListModels = %+v, %v; want %+v", models, err, want)
+	}
+}
@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// openAIProvider talks to OpenAI-compatible chat completion endpoints,
+// such as a LiteLLM proxy
+type openAIProvider struct {
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// CompletionResponse represents an API response
+type CompletionResponse struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// CompletionChunk is one server-sent event of a streamed response
+type CompletionChunk struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// modelList is the response of /v1/models
+type modelList struct {
+	Data []Model `json:"data"`
+}
+
+func newOpenAIProvider(baseURL, apiKey string) *openAIProvider {
+	client, stream := httpClients()
+	return &openAIProvider{baseURL: baseURL, apiKey: apiKey, httpClient: client, streamClient: stream}
+}
+
+// headers returns the authentication headers
+func (p *openAIProvider) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + p.apiKey}
+}
+
+// Complete sends the request and returns the first choice
+func (p *openAIProvider) Complete(ctx context.Context, req CompletionRequest) (string, error) {
+	req.Stream = false
+	httpResp, err := doJSON(ctx, p.httpClient, "POST", p.baseURL+"/v1/chat/completions", p.headers(), req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp CompletionResponse
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", nil
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// Stream sends the request with streaming enabled. A server that ignores
+// stream and answers with plain JSON is handled too.
+func (p *openAIProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (string, error) {
+	req.Stream = true
+	ctx, wrap, stop := idleContext(ctx)
+	defer stop()
+
+	headers := p.headers()
+	headers["Accept"] = "text/event-stream"
+	httpResp, err := doJSON(ctx, p.streamClient, "POST", p.baseURL+"/v1/chat/completions", headers, req)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	if !strings.HasPrefix(httpResp.Header.Get("Content-Type"), "text/event-stream") {
+		var resp CompletionResponse
+		if err := decodeJSON(httpResp, &resp); err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", nil
+		}
+		content := resp.Choices[0].Message.Content
+		if onDelta != nil && content != "" {
+			onDelta(content)
+		}
+		return content, nil
+	}
+
+	var content strings.Builder
+	err = readEvents(wrap(httpResp.Body), func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return false, nil
+		}
+		var chunk CompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return content.String(), nil
+}
+
+// ListModels returns the models listed at /v1/models
+func (p *openAIProvider) ListModels(ctx context.Context) ([]Model, error) {
+	httpResp, err := doJSON(ctx, p.httpClient, "GET", p.baseURL+"/v1/models", p.headers(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list modelList
+	if err := decodeJSON(httpResp, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
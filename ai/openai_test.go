@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req CompletionRequest
+		decodeBody(t, r, &req)
+		if req.Model != "test-model" || req.Stream || len(req.Messages) != 2 {
+			t.Errorf("request body = %+v", req)
+		}
+		fmt.Fprint(w, `{"id":"1","choices":[{"message":{"role":"assistant","content":"hello"}}]}`)
+	}))
+	defer srv.Close()
+
+	p := newOpenAIProvider(srv.URL, "sk-test")
+	got, err := p.Complete(context.Background(), testRequest)
+	if err != nil || got != "hello" {
+		t.Errorf("Complete = %q, %v; want hello", got, err)
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CompletionRequest
+		decodeBody(t, r, &req)
+		if !req.Stream {
+			t.Error("stream was not requested")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		// Nothing after [DONE] is part of the answer
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"!\"}}]}\n\n")
+	}))
+	defer srv.Close()
+
+	onDelta, deltas := collectDeltas()
+	got, err := newOpenAIProvider(srv.URL, "sk-test").Stream(context.Background(), testRequest, onDelta)
+	if err != nil || got != "Hello" {
+		t.Errorf("Stream = %q, %v; want Hello", got, err)
+	}
+	if !reflect.DeepEqual(*deltas, []string{"Hel", "lo"}) {
+		t.Errorf("deltas = %q", *deltas)
+	}
+}
+
+func TestOpenAIStreamFallsBackToJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"whole answer"}}]}`)
+	}))
+	defer srv.Close()
+
+	onDelta, deltas := collectDeltas()
+	got, err := newOpenAIProvider(srv.URL, "sk-test").Stream(context.Background(), testRequest, onDelta)
+	if err != nil || got != "whole answer" {
+		t.Errorf("Stream = %q, %v; want whole answer", got, err)
+	}
+	if !reflect.DeepEqual(*deltas, []string{"whole answer"}) {
+		t.Errorf("deltas = %q", *deltas)
+	}
+}
+
+func TestOpenAIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid key"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	_, err := newOpenAIProvider(srv.URL, "sk-bad").Complete(context.Background(), testRequest)
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid key") {
+		t.Errorf("err = %v, want the status and body", err)
+	}
+}
+
+func TestOpenAIListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1/models" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"data":[{"id":"qwen3-terminal","owned_by":"litellm"},{"id":"gpt-4o"}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := newOpenAIProvider(srv.URL, "sk-test").ListModels(context.Background())
+	want := []Model{{ID: "qwen3-terminal", OwnedBy: "litellm"}, {ID: "gpt-4o"}}
+	if err != nil || !reflect.DeepEqual(models, want) {
+		t.Errorf("ListModels = %+v, %v; want %+v", models, err, want)
+	}
+}
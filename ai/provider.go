@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider types
+const (
+	// ProviderOpenAI speaks the OpenAI chat completions API, as served by
+	// LiteLLM, OpenAI and most self-hosted gateways
+	ProviderOpenAI = "openai"
+	// ProviderOllama speaks Ollama's native chat API
+	ProviderOllama = "ollama"
+	// ProviderAnthropic speaks the Anthropic Messages API
+	ProviderAnthropic = "anthropic"
+)
+
+// requestTimeout bounds requests that are not streamed
+const requestTimeout = 30 * time.Second
+
+// ErrNotConfigured is returned by NewProvider when a provider that needs
+// an API key or endpoint has none
+var ErrNotConfigured = errors.New("AI provider not configured")
+
+// Provider sends chat requests to a model API
+type Provider interface {
+	// Complete returns the whole answer to a request
+	Complete(ctx context.Context, req CompletionRequest) (string, error)
+	// Stream passes each piece of the answer to onDelta as it arrives and
+	// returns the whole answer
+	Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (string, error)
+	// ListModels returns the models the API serves
+	ListModels(ctx context.Context) ([]Model, error)
+}
+
+// ProviderConfig selects and configures a provider
+type ProviderConfig struct {
+	// Type is one of the Provider constants; empty means ProviderOpenAI
+	Type string
+	// BaseURL is the API root, without /v1. Empty uses the public default
+	// for Ollama and Anthropic; ProviderOpenAI needs one, so that a key meant
+	// for a proxy is never sent to OpenAI by accident.
+	BaseURL string
+	APIKey  string
+}
+
+// NewProvider creates the provider described by cfg
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	switch cfg.Type {
+	case "", ProviderOpenAI:
+		if cfg.APIKey == "" || cfg.BaseURL == "" {
+			return nil, ErrNotConfigured
+		}
+		return newOpenAIProvider(baseURLOrDefault(cfg.BaseURL, ""), cfg.APIKey), nil
+	case ProviderOllama:
+		return newOllamaProvider(baseURLOrDefault(cfg.BaseURL, "http://localhost:11434"), cfg.APIKey), nil
+	case ProviderAnthropic:
+		if cfg.APIKey == "" {
+			return nil, ErrNotConfigured
+		}
+		return newAnthropicProvider(baseURLOrDefault(cfg.BaseURL, "https://api.anthropic.com"), cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q", cfg.Type)
+	}
+}
+
+// baseURLOrDefault returns the configured API root without a trailing
+// slash, or the default when none is configured
+func baseURLOrDefault(baseURL, def string) string {
+	if baseURL == "" {
+		return def
+	}
+	return strings.TrimSuffix(baseURL, "/")
+}
+
+// httpClients returns a client for ordinary requests and one for streams.
+// Streams have no overall timeout, since they last as long as the answer;
+// they time out when idle instead.
+func httpClients() (*http.Client, *http.Client) {
+	return &http.Client{Timeout: requestTimeout}, &http.Client{}
+}
+
+// doJSON sends a request with an optional JSON body and returns the
+// response if its status is 200
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		body, _ := io.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s", httpResp.StatusCode, string(body))
+	}
+
+	return httpResp, nil
+}
+
+// decodeJSON decodes a response body and closes it
+func decodeJSON(httpResp *http.Response, v interface{}) error {
+	defer httpResp.Body.Close()
+	if err := json.NewDecoder(httpResp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNewProviderNeedsConfiguration(t *testing.T) {
+	for name, cfg := range map[string]ProviderConfig{
+		"openai without key":       {Type: ProviderOpenAI, BaseURL: "http://localhost:4000"},
+		"openai without endpoint":  {Type: ProviderOpenAI, APIKey: "sk-proxy"},
+		"default without endpoint": {APIKey: "sk-proxy"},
+		"anthropic without key":    {Type: ProviderAnthropic},
+	} {
+		if _, err := NewProvider(cfg); !errors.Is(err, ErrNotConfigured) {
+			t.Errorf("%s: err = %v, want ErrNotConfigured", name, err)
+		}
+	}
+
+	if _, err := NewProvider(ProviderConfig{Type: ProviderOllama}); err != nil {
+		t.Errorf("ollama without key or endpoint: %v", err)
+	}
+	if _, err := NewProvider(ProviderConfig{Type: "other"}); err == nil || errors.Is(err, ErrNotConfigured) {
+		t.Errorf("unknown provider: err = %v", err)
+	}
+}
+
+// testRequest is the chat request the provider tests send
+var testRequest = CompletionRequest{
+	Model: "test-model",
+	Messages: []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "hi"},
+	},
+	MaxTokens: 50,
+}
+
+// decodeBody decodes a JSON request body into v
+func decodeBody(t *testing.T, r *http.Request, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("failed to decode request body: %v", err)
+	}
+}
+
+// collectDeltas returns an onDelta callback and the deltas it has seen
+func collectDeltas() (func(string), *[]string) {
+	var deltas []string
+	return func(d string) { deltas = append(deltas, d) }, &deltas
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// streamIdleTimeout is how long a stream may go without sending anything,
+// which includes waiting for a cold endpoint to start
+const streamIdleTimeout = 2 * time.Minute
+
+// maxStreamLine bounds a single line of a streamed response
+const maxStreamLine = 1024 * 1024
+
+// idleContext returns a context that is cancelled once a stream read
+// through wrap has been silent for streamIdleTimeout. stop releases it.
+func idleContext(ctx context.Context) (idleCtx context.Context, wrap func(io.Reader) io.Reader, stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(streamIdleTimeout, cancel)
+	wrap = func(r io.Reader) io.Reader {
+		return &idleReader{r: r, timer: timer}
+	}
+	return ctx, wrap, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// idleReader restarts an idle timer whenever data arrives, including
+// keep-alive comments that produce no events
+type idleReader struct {
+	r     io.Reader
+	timer *time.Timer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(streamIdleTimeout)
+	}
+	return n, err
+}
+
+// readEvents reads server-sent events and calls onData with the data of
+// each one, joining multi-line data with newlines. It stops at the end of
+// the stream or when onData returns false or an error.
+func readEvents(r io.Reader, onData func(data string) (bool, error)) error {
+	var data []string
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			return true, nil
+		}
+		event := strings.Join(data, "\n")
+		data = data[:0]
+		return onData(event)
+	}
+
+	err := readLines(r, func(line string) (bool, error) {
+		switch {
+		case line == "":
+			// A blank line ends the event
+			return dispatch()
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// Comments, often sent as keep-alives, and event names are not needed
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	// The stream may end without a final blank line
+	_, err = dispatch()
+	return err
+}
+
+// readLines calls onLine with each line of r, as in newline-delimited
+// JSON. It stops at the end of r or when onLine returns false or an error.
+func readLines(r io.Reader, onLine func(line string) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		more, err := onLine(scanner.Text())
+		if err != nil || !more {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -2,7 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"ai-terminal-pro/ai"
@@ -15,10 +21,19 @@ import (
 // App struct
 type App struct {
 	ctx       context.Context
-	settings  *config.Settings
 	validator *security.Validator
-	client    *ai.Client
-	terminal  *terminal.PTYSession
+	// sessions is created in OnStartup and never replaced
+	sessions *terminal.SessionManager
+
+	// mu guards the fields below, which SaveSettings can replace at any time
+	mu       sync.RWMutex
+	settings *config.Settings
+	client   *ai.Client
+	// restorable holds sessions saved by the last run until they are
+	// restored or discarded
+	restorable []terminal.SavedSession
+
+	closeOnce sync.Once
 }
 
 // NewApp creates a new App application struct
@@ -36,29 +51,38 @@ func (a *App) OnStartup(ctx context.Context) {
 		fmt.Printf("Failed to load config: %v\n", err)
 		settings = config.DefaultSettings()
 	}
-	a.settings = settings
 
 	// Initialize security validator
 	a.validator = security.NewValidator()
 
-	// Initialize AI client if configured
-	if settings.LiteLLMEndpoint != "" && settings.VirtualKey != "" {
-		a.client = ai.NewClient(settings.LiteLLMEndpoint, settings.VirtualKey)
-	}
-
-	// Start terminal session
-	ptySession, err := terminal.NewPTYSession()
-	if err != nil {
-		fmt.Printf("Failed to start terminal: %v\n", err)
-		// Emit error event to frontend
-		runtime.EventsEmit(ctx, "terminal-error", fmt.Sprintf("Failed to start terminal: %v", err))
-		return
+	a.mu.Lock()
+	a.settings = settings
+	a.client = newAIClient(settings)
+	a.mu.Unlock()
+
+	// Sessions are created on demand by the frontend (one per tab or pane).
+	// Each session ends when the app context is cancelled.
+	a.sessions = terminal.NewSessionManager(ctx, terminal.Hooks{
+		Output:  a.emitOutput,
+		Exit:    a.emitExit,
+		Command: a.emitCommand,
+	})
+	a.sessions.SetShellIntegration(settings.ShellIntegration)
+	a.useSessionDaemon(settings.SessionDaemon)
+
+	// Sessions from the last run are offered to the frontend, which asks
+	// the user whether to restore them
+	if settings.RestoreSessions {
+		if path, err := savedSessionsPath(); err == nil {
+			saved, err := terminal.LoadSavedSessions(path)
+			if err != nil {
+				fmt.Printf("Failed to load saved sessions: %v\n", err)
+			}
+			a.mu.Lock()
+			a.restorable = saved
+			a.mu.Unlock()
+		}
 	}
-	a.terminal = ptySession
-	fmt.Printf("Terminal started successfully with shell: %s\n", ptySession.GetShell())
-
-	// Start goroutine to read PTY output and emit to frontend
-	go a.readTerminalOutput()
 }
 
 // OnDomReady is called after front-end resources have been loaded
@@ -66,54 +90,99 @@ func (a *App) OnDomReady(ctx context.Context) {
 	// Frontend is ready
 }
 
-// readTerminalOutput continuously reads from PTY and emits events to frontend
-func (a *App) readTerminalOutput() {
-	buf := make([]byte, 4096)
-	fmt.Println("Starting terminal output reader goroutine...")
-	
-	for {
-		if a.terminal == nil {
-			time.Sleep(100 * time.Millisecond)
-			continue
-		}
-		
-		n, err := a.terminal.Read(buf)
-		if err != nil {
-			// PTY closed or error - only log once to avoid spam
-			time.Sleep(100 * time.Millisecond)
-			continue
-		}
-		
-		if n > 0 {
-			data := string(buf[:n])
-			fmt.Printf("PTY output (%d bytes): %q\n", n, data[:min(n, 50)])
-			// Emit terminal output event to frontend
-			runtime.EventsEmit(a.ctx, "terminal-output", data)
-		}
-		
-		time.Sleep(10 * time.Millisecond) // Small delay to prevent CPU spinning
-	}
+// outputEvent returns the name of the event carrying a session's output
+func outputEvent(sessionID string) string {
+	return "terminal-output:" + sessionID
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// exitedEvent returns the name of the event sent when a session's shell exits
+func exitedEvent(sessionID string) string {
+	return "terminal-exited:" + sessionID
+}
+
+// commandEvent returns the name of the event sent when a command finishes in a session
+func commandEvent(sessionID string) string {
+	return "terminal-command:" + sessionID
+}
+
+// aiTokenEvent returns the name of the event carrying partial AI answers
+// generated for a session
+func aiTokenEvent(sessionID string) string {
+	return "ai-token:" + sessionID
+}
+
+// emitOutput forwards a frame of session output to the frontend
+func (a *App) emitOutput(sessionID string, frame terminal.Frame) {
+	runtime.EventsEmit(a.ctx, outputEvent(sessionID), frame)
+}
+
+// emitExit notifies the frontend that a session's shell has exited
+func (a *App) emitExit(sessionID string, status terminal.ExitStatus) {
+	fmt.Printf("Terminal %s exited with code %d\n", sessionID, status.Code)
+	runtime.EventsEmit(a.ctx, exitedEvent(sessionID), status)
+}
+
+// emitCommand notifies the frontend that a shell command has finished
+func (a *App) emitCommand(sessionID string, record terminal.CommandRecord) {
+	runtime.EventsEmit(a.ctx, commandEvent(sessionID), record)
 }
 
 // OnBeforeClose is called when the application is about to quit
 func (a *App) OnBeforeClose(ctx context.Context) bool {
-	// Clean up terminal session
-	if a.terminal != nil {
-		a.terminal.Close()
-	}
+	a.closeSessions()
 	return false
 }
 
 // OnShutdown is called at application termination
 func (a *App) OnShutdown(ctx context.Context) {
-	// Cleanup resources
+	// Sessions are normally gone by now; this covers quitting without
+	// closing the window
+	a.closeSessions()
+}
+
+// closeSessions saves the sessions for the next run, then shuts them all
+// down and logs processes that could not be stopped. Only the first call
+// does anything, so OnShutdown does not overwrite what OnBeforeClose saved.
+func (a *App) closeSessions() {
+	if a.sessions == nil {
+		return
+	}
+	a.closeOnce.Do(func() {
+		a.saveSessions()
+		for _, report := range a.sessions.CloseAll() {
+			logShutdown(report)
+		}
+	})
+}
+
+// saveSessions writes the open sessions to the config directory. Sessions
+// from the last run that were not restored are dropped, so the file only
+// ever holds one run's sessions.
+func (a *App) saveSessions() {
+	settings := a.getSettings()
+	path, err := savedSessionsPath()
+	if err != nil {
+		fmt.Printf("Failed to save sessions: %v\n", err)
+		return
+	}
+
+	var saved []terminal.SavedSession
+	if settings.RestoreSessions {
+		saved = a.sessions.SaveAll()
+	}
+	if err := terminal.SaveSessions(path, saved); err != nil {
+		fmt.Printf("Failed to save sessions: %v\n", err)
+	}
+}
+
+// logShutdown logs processes that outlived a session
+func logShutdown(report terminal.ShutdownReport) {
+	if report.Forced {
+		fmt.Printf("Terminal %s: processes ignored hangup and were killed\n", report.SessionID)
+	}
+	for _, proc := range report.Survivors {
+		fmt.Printf("Terminal %s: process %d (%s) survived shutdown\n", report.SessionID, proc.Pid, proc.Name)
+	}
 }
 
 // Greet returns a greeting for the given name
@@ -123,35 +192,98 @@ func (a *App) Greet(name string) string {
 
 // GetOS returns the current operating system
 func (a *App) GetOS() string {
-	return a.settings.GetOSType()
+	return a.getSettings().GetOSType()
+}
+
+// getSettings returns the current settings under the read lock
+func (a *App) getSettings() *config.Settings {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.settings
+}
+
+// newAIClient creates the AI client for the settings' provider, or
+// returns nil if it is not configured
+func newAIClient(settings *config.Settings) *ai.Client {
+	provider, err := ai.NewProvider(ai.ProviderConfig{
+		Type:    settings.Provider,
+		BaseURL: settings.LiteLLMEndpoint,
+		APIKey:  settings.VirtualKey,
+	})
+	if err != nil {
+		if !errors.Is(err, ai.ErrNotConfigured) {
+			fmt.Printf("Failed to create AI client: %v\n", err)
+		}
+		return nil
+	}
+	return ai.NewClientWithProvider(provider, settings.Model)
+}
+
+// ListModels returns the models served by the configured provider, so
+// the model can be chosen in settings
+func (a *App) ListModels() ([]ai.Model, error) {
+	client := a.getClient()
+	if client == nil {
+		return nil, fmt.Errorf("AI client not configured")
+	}
+	return client.ListModels(a.ctx)
+}
+
+// getClient returns the AI client under the read lock
+func (a *App) getClient() *ai.Client {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.client
 }
 
-// GenerateCommand generates a terminal command using AI
-func (a *App) GenerateCommand(description string) (map[string]interface{}, error) {
-	if a.client == nil {
+// GenerateCommand generates a terminal command using AI. The working
+// directory of sessionID, if known, is included in the prompt context.
+// The answer is streamed to the frontend as it is generated through
+// ai-token:<sessionID> events.
+func (a *App) GenerateCommand(sessionID, description string) (map[string]interface{}, error) {
+	client := a.getClient()
+	if client == nil {
 		return nil, fmt.Errorf("AI client not configured")
 	}
 
+	settings := a.getSettings()
 	aiCtx := ai.Context{
-		OS:         a.settings.GetOSType(),
-		Shell:      a.settings.GetShell(),
-		WorkingDir: ".", // TODO: Get actual working directory
+		OS:         settings.GetOSType(),
+		Shell:      settings.GetShell(),
+		WorkingDir: ".",
 	}
+	session, err := a.sessions.Get(sessionID)
+	if err == nil {
+		aiCtx.Shell = filepath.Base(session.GetShell())
+		if dir, err := session.WorkingDir(); err == nil {
+			aiCtx.WorkingDir = dir.Path
+		}
+	}
+	aiCtx.Dialect = terminal.ShellDialect(aiCtx.Shell)
 
-	command, err := a.client.GenerateCommand(a.ctx, description, aiCtx)
+	// Partial answers are shown as they stream in; the final command is
+	// only usable once it has been validated below
+	command, err := client.GenerateCommandStream(a.ctx, description, aiCtx, func(token string) {
+		runtime.EventsEmit(a.ctx, aiTokenEvent(sessionID), token)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate the command
-	risk := a.validator.ValidateCommand(command)
+	// Validate the command in the syntax of the shell it is meant for
+	risk := a.validator.ValidateCommandFor(command, aiCtx.Dialect)
 	explanation := a.validator.GetExplanation(risk)
 
+	// The frontend must not type the command into a session while an
+	// interactive program such as vim owns the terminal
+	busy := session != nil && session.Busy()
+
 	return map[string]interface{}{
 		"command":     command,
 		"risk":        risk.String(),
 		"explanation": explanation,
 		"blocked":     risk == security.RiskCritical,
+		"busy":        busy,
 	}, nil
 }
 
@@ -170,58 +302,638 @@ func (a *App) ValidateCommand(command string) map[string]interface{} {
 
 // GetSettings returns the current application settings
 func (a *App) GetSettings() *config.Settings {
-	return a.settings
+	return a.getSettings()
 }
 
 // SaveSettings saves the application settings
 func (a *App) SaveSettings(settings *config.Settings) error {
+	// The key is kept in the keyring rather than sent with the settings
+	if settings.VirtualKey == "" {
+		settings.VirtualKey = a.getSettings().VirtualKey
+	}
 	if err := settings.Save(); err != nil {
 		return err
 	}
+	a.mu.Lock()
 	a.settings = settings
+	// The endpoint, key or model may have changed
+	a.client = newAIClient(settings)
+	a.mu.Unlock()
+	// Applies to sessions started from now on
+	a.sessions.SetShellIntegration(settings.ShellIntegration)
+	a.useSessionDaemon(settings.SessionDaemon)
 	return nil
 }
 
-// WriteToTerminal writes data to the terminal
-func (a *App) WriteToTerminal(data string) error {
-	if a.terminal == nil {
-		return fmt.Errorf("terminal not initialized")
+// useSessionDaemon starts new local shells in the session daemon when
+// enabled, starting the daemon if needed, and in-process otherwise
+func (a *App) useSessionDaemon(enabled bool) {
+	if !enabled {
+		a.sessions.SetDaemon(nil)
+		return
+	}
+	client, err := terminal.ConnectDaemon(terminal.DaemonSocketPath())
+	if err != nil {
+		fmt.Printf("Failed to connect to session daemon: %v\n", err)
+		a.sessions.SetDaemon(nil)
+		return
+	}
+	a.sessions.SetDaemon(client)
+}
+
+// AttachDaemonSessions reattaches to shells left running in the session
+// daemon, replaying their recent output, and returns the new sessions
+func (a *App) AttachDaemonSessions() ([]terminal.SessionInfo, error) {
+	sessions, err := a.sessions.AttachDaemonSessions()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]terminal.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		fmt.Printf("Terminal %s reattached: %s\n", session.ID(), session.GetShell())
+		infos = append(infos, session.Info())
+	}
+	return infos, nil
+}
+
+// CreateSession starts a new terminal session and returns its ID.
+// An empty shellPath launches the default shell profile.
+func (a *App) CreateSession(shellPath string) (string, error) {
+	if shellPath == "" {
+		return a.CreateSessionFromProfile("")
 	}
-	fmt.Printf("WriteToTerminal: %q\n", data)
-	_, err := a.terminal.Write([]byte(data))
+	session, err := a.sessions.Create(shellPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to start terminal: %w", err)
+	}
+	fmt.Printf("Terminal %s started with shell: %s\n", session.ID(), session.GetShell())
+
+	return session.ID(), nil
+}
+
+// CreateSessionFromProfile starts a new terminal session from a named shell
+// profile and returns its ID. An empty name uses the default profile.
+func (a *App) CreateSessionFromProfile(profileName string) (string, error) {
+	settings := a.getSettings()
+	profile, err := settings.Profile(profileName)
+	if err != nil {
+		return "", err
+	}
+	session, err := a.sessions.CreateWithOptions(launchOptions(settings, profile))
+	if err != nil {
+		return "", fmt.Errorf("failed to start terminal with profile %q: %w", profile.Name, err)
+	}
+	fmt.Printf("Terminal %s started with profile %s: %s\n", session.ID(), profile.Name, session.GetShell())
+
+	return session.ID(), nil
+}
+
+// CreateSSHSession connects to a remote host and returns the ID of a new
+// session running the remote login shell. Host keys are checked against
+// known_hosts.
+func (a *App) CreateSSHSession(cfg terminal.SSHConfig) (string, error) {
+	session, err := a.sessions.CreateSSH(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", cfg.Host, err)
+	}
+	fmt.Printf("Terminal %s connected to %s\n", session.ID(), session.GetShell())
+
+	return session.ID(), nil
+}
+
+// GetSSHProfiles returns the hosts defined in ~/.ssh/config as ready-made
+// session profiles
+func (a *App) GetSSHProfiles() ([]terminal.SSHProfile, error) {
+	return terminal.LoadSSHProfiles()
+}
+
+// CreateSessionFromSSHProfile connects to a host from ~/.ssh/config by its
+// alias and returns the new session's ID
+func (a *App) CreateSessionFromSSHProfile(name string) (string, error) {
+	profiles, err := terminal.LoadSSHProfiles()
+	if err != nil {
+		return "", err
+	}
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return a.CreateSSHSession(profile.Config)
+		}
+	}
+	return "", fmt.Errorf("ssh host %q not found in ~/.ssh/config", name)
+}
+
+// ListTmuxSessions returns the sessions running on the tmux server
+func (a *App) ListTmuxSessions() ([]terminal.TmuxSessionInfo, error) {
+	return terminal.ListTmuxSessions()
+}
+
+// AttachTmux attaches to a tmux session in control mode, creating it if it
+// does not exist. Each tmux pane opens as its own session; they are reported
+// through ListSessions with kind "tmux".
+func (a *App) AttachTmux(name string) error {
+	return a.sessions.AttachTmux(name)
+}
+
+// DetachTmux detaches from a tmux session, leaving its programs running
+func (a *App) DetachTmux(name string) error {
+	return a.sessions.DetachTmux(name)
+}
+
+// GetProfiles returns the configured shell profiles
+func (a *App) GetProfiles() []config.ShellProfile {
+	return a.getSettings().Profiles
+}
+
+// launchOptions converts a shell profile into terminal launch options
+func launchOptions(settings *config.Settings, profile config.ShellProfile) terminal.LaunchOptions {
+	env := make([]string, 0, len(profile.Env))
+	for key, value := range profile.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	return terminal.LaunchOptions{
+		Profile:         profile.Name,
+		Shell:           profile.Shell,
+		Args:            profile.Args,
+		Login:           profile.Login,
+		Dir:             profile.Dir,
+		Env:             env,
+		UnsetEnv:        profile.UnsetEnv,
+		StartupCommands: profile.StartupCommands,
+		Integration:     settings.IntegrationEnabled(profile),
+	}
+}
+
+// ListSessions returns all open terminal sessions
+func (a *App) ListSessions() []terminal.SessionInfo {
+	return a.sessions.List()
+}
+
+// CloseSession terminates a terminal session and reports processes that
+// had to be killed or survived
+func (a *App) CloseSession(sessionID string) (terminal.ShutdownReport, error) {
+	report, err := a.sessions.Close(sessionID)
+	if err != nil {
+		return report, err
+	}
+	logShutdown(report)
+	return report, nil
+}
+
+// WriteToTerminal writes data to a terminal session, and to the rest of its
+// broadcast group if it is in one. Commands submitted to a group are
+// validated for every target shell first; if any would be refused, or the
+// line cannot be known, nothing is sent. With safety checks off, input is
+// mirrored unchecked.
+func (a *App) WriteToTerminal(sessionID, data string) error {
+	var check terminal.BroadcastCheck
+	if a.getSettings().SafetyMode != "off" {
+		check = a.checkBroadcast
+	}
+	err := a.sessions.Broadcast(sessionID, []byte(data), check)
 	if err != nil {
 		fmt.Printf("WriteToTerminal error: %v\n", err)
 	}
 	return err
 }
 
-// ReadFromTerminal reads data from the terminal
-func (a *App) ReadFromTerminal() (string, error) {
-	if a.terminal == nil {
-		return "", fmt.Errorf("terminal not initialized")
+// checkBroadcast validates a command line about to be submitted to a
+// session in a broadcast group, in the syntax of that session's shell.
+// Strict safety mode also refuses commands that would only warn.
+func (a *App) checkBroadcast(target *terminal.Session, line string) error {
+	mode := a.getSettings().SafetyMode
+	// A busy session's line goes to whatever program is running, which may
+	// well be a shell on another host, so it is checked all the same
+	risk := a.validator.ValidateCommandFor(line, terminal.ShellDialect(target.GetShell()))
+	if risk == security.RiskCritical || (mode == "strict" && risk >= security.RiskMedium) {
+		return fmt.Errorf("broadcast refused for %s: %s", target.ID(), a.validator.GetExplanation(risk))
+	}
+	return nil
+}
+
+// CreateBroadcastGroup creates a group of sessions whose input is mirrored
+// to each other. Sessions already in another group are moved.
+func (a *App) CreateBroadcastGroup(name string, sessionIDs []string) error {
+	return a.sessions.CreateBroadcastGroup(name, sessionIDs)
+}
+
+// DeleteBroadcastGroup ends a broadcast group
+func (a *App) DeleteBroadcastGroup(name string) error {
+	return a.sessions.DeleteBroadcastGroup(name)
+}
+
+// AddToBroadcastGroup moves a session into a broadcast group
+func (a *App) AddToBroadcastGroup(name, sessionID string) error {
+	return a.sessions.AddToBroadcastGroup(name, sessionID)
+}
+
+// RemoveFromBroadcastGroup takes a session out of a broadcast group
+func (a *App) RemoveFromBroadcastGroup(name, sessionID string) error {
+	return a.sessions.RemoveFromBroadcastGroup(name, sessionID)
+}
+
+// ListBroadcastGroups returns the broadcast groups and their sessions
+func (a *App) ListBroadcastGroups() []terminal.BroadcastGroupInfo {
+	return a.sessions.ListBroadcastGroups()
+}
+
+// AckTerminalOutput acknowledges output frames up to seq once the frontend has rendered them.
+// Reads from the PTY pause while too much output is unacknowledged.
+func (a *App) AckTerminalOutput(sessionID string, seq uint64) error {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	session.Ack(seq)
+	return nil
+}
+
+// ResetTerminalOutput clears a session's flow-control state and replays its
+// retained output. The frontend calls this when it (re)subscribes to a session,
+// since it never saw, and cannot ack, the frames sent before.
+func (a *App) ResetTerminalOutput(sessionID string) error {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	session.ResetFlow()
+	return nil
+}
+
+// SetOutputEncoding selects "utf8" or "base64" frames for a session's output
+func (a *App) SetOutputEncoding(sessionID, encoding string) error {
+	enc, err := terminal.ParseOutputEncoding(encoding)
+	if err != nil {
+		return err
+	}
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	session.SetEncoding(enc)
+	return nil
+}
+
+// GetScrollbackLines returns up to count plain-text lines of a session's
+// history, starting at absolute line number start
+func (a *App) GetScrollbackLines(sessionID string, start int64, count int) (terminal.LineRange, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return terminal.LineRange{}, err
+	}
+	return session.Scrollback().Lines(start, count), nil
+}
+
+// SearchScrollback searches a session's plain-text history for query
+func (a *App) SearchScrollback(sessionID, query string, opts terminal.SearchOptions) ([]terminal.Match, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return session.Scrollback().Search(query, opts)
+}
+
+// GetScrollbackRaw returns a session's retained raw output, base64 encoded,
+// so a reloaded frontend can rebuild its screen
+func (a *App) GetScrollbackRaw(sessionID string) (string, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(session.Scrollback().Raw()), nil
+}
+
+// GetScreenSnapshot returns what is currently displayed in a session.
+// Styled spans with colors and attributes are included when styled is true.
+func (a *App) GetScreenSnapshot(sessionID string, styled bool) (terminal.ScreenSnapshot, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return terminal.ScreenSnapshot{}, err
+	}
+	return session.Screen().Snapshot(styled), nil
+}
+
+// GetCommandHistory returns the commands run in a session, oldest first.
+// Commands are only tracked when the shell emits OSC 133 marks.
+func (a *App) GetCommandHistory(sessionID string) ([]terminal.CommandRecord, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return session.Commands().Commands(), nil
+}
+
+// GetLastCommand returns the most recently finished command in a session
+func (a *App) GetLastCommand(sessionID string) (*terminal.CommandRecord, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := session.Commands().Last()
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// GetWorkingDir returns the current directory of a session, for display in
+// the status bar
+func (a *App) GetWorkingDir(sessionID string) (terminal.WorkingDir, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return terminal.WorkingDir{}, err
+	}
+	return session.WorkingDir()
+}
+
+// GetForegroundProcess returns the program that owns a session's terminal,
+// which is the shell itself when no job is running
+func (a *App) GetForegroundProcess(sessionID string) (terminal.ForegroundProcess, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return terminal.ForegroundProcess{}, err
+	}
+	return session.Foreground()
+}
+
+// IsSessionBusy reports whether a job is running in a session, so the
+// frontend can confirm before closing its tab
+func (a *App) IsSessionBusy(sessionID string) (bool, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return false, err
+	}
+	return session.Busy(), nil
+}
+
+// SendSignal delivers a signal such as "SIGINT" or "SIGKILL" to a session.
+// target is "foreground" for the running job or "session" for every process
+// in the terminal, which reaches programs that ignore typed control keys.
+func (a *App) SendSignal(sessionID, signal, target string) error {
+	t, err := terminal.ParseSignalTarget(target)
+	if err != nil {
+		return err
+	}
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	return session.Signal(signal, t)
+}
+
+// recordingsDir returns where session recordings are stored
+func recordingsDir() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "recordings"), nil
+}
+
+// StartRecording starts recording a session to an asciicast v2 file and
+// returns its path. Keystrokes are included only when recordInput is true.
+func (a *App) StartRecording(sessionID string, recordInput bool) (string, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return "", err
+	}
+	dir, err := recordingsDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s.cast", time.Now().Format("20060102-150405"), sessionID)
+	path := filepath.Join(dir, name)
+	if err := session.StartRecording(path, recordInput); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// StopRecording finishes a session's recording and returns the file path
+func (a *App) StopRecording(sessionID string) (string, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return "", err
+	}
+	return session.StopRecording()
+}
+
+// savedSessionsPath returns where sessions are saved between runs
+func savedSessionsPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sessions.json"), nil
+}
+
+// RestorableSession describes a session saved by the last run
+type RestorableSession struct {
+	Profile string    `json:"profile,omitempty"`
+	Shell   string    `json:"shell"`
+	Dir     string    `json:"dir,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// GetRestorableSessions returns the sessions saved when the app last
+// exited, so the frontend can offer to restore them
+func (a *App) GetRestorableSessions() []RestorableSession {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	sessions := make([]RestorableSession, 0, len(a.restorable))
+	for _, saved := range a.restorable {
+		sessions = append(sessions, RestorableSession{
+			Profile: saved.Profile,
+			Shell:   saved.Shell,
+			Dir:     saved.Dir,
+			Title:   saved.Title,
+			SavedAt: saved.SavedAt,
+		})
+	}
+	return sessions
+}
+
+// RestoreSessions recreates the sessions saved by the last run, each in a
+// fresh shell below its saved scrollback, and returns them
+func (a *App) RestoreSessions() ([]terminal.SessionInfo, error) {
+	a.mu.Lock()
+	restorable := a.restorable
+	a.restorable = nil
+	a.mu.Unlock()
+
+	settings := a.getSettings()
+	infos := make([]terminal.SessionInfo, 0, len(restorable))
+	var errs []error
+	for _, saved := range restorable {
+		// Sessions started without a profile keep their shell
+		profile, err := settings.Profile(saved.Profile)
+		if err != nil || saved.Profile == "" {
+			profile = config.ShellProfile{Name: saved.Profile, Shell: saved.Shell, Login: true}
+		}
+		session, err := a.sessions.Restore(saved, launchOptions(settings, profile))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", saved.Shell, err))
+			continue
+		}
+		fmt.Printf("Terminal %s restored: %s\n", session.ID(), session.GetShell())
+		infos = append(infos, session.Info())
 	}
-	buf := make([]byte, 4096)
-	n, err := a.terminal.Read(buf)
+	return infos, errors.Join(errs...)
+}
+
+// DiscardRestorableSessions forgets the sessions saved by the last run
+func (a *App) DiscardRestorableSessions() error {
+	a.mu.Lock()
+	a.restorable = nil
+	a.mu.Unlock()
+
+	path, err := savedSessionsPath()
+	if err != nil {
+		return err
+	}
+	return terminal.SaveSessions(path, nil)
+}
+
+// RecordingInfo describes a saved recording
+type RecordingInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// ListRecordings returns the recordings in the config directory, newest first
+func (a *App) ListRecordings() ([]RecordingInfo, error) {
+	dir, err := recordingsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []RecordingInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recordings: %w", err)
+	}
+
+	recordings := []RecordingInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".cast" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, RecordingInfo{
+			Name:    entry.Name(),
+			Path:    filepath.Join(dir, entry.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].ModTime.After(recordings[j].ModTime)
+	})
+	return recordings, nil
+}
+
+// OpenRecording plays an asciicast file in a new read-only session and
+// returns its ID. Output arrives on the same events as a live session.
+// Pauses longer than idleLimit seconds are shortened; 0 uses the file's own limit.
+func (a *App) OpenRecording(path string, speed, idleLimit float64) (string, error) {
+	player, err := terminal.OpenPlayer(path, speed, idleLimit)
 	if err != nil {
 		return "", err
 	}
-	return string(buf[:n]), nil
+	session := a.sessions.Adopt(player)
+	if rows, cols := player.Size(); rows > 0 && cols > 0 {
+		session.Resize(rows, cols)
+	}
+	return session.ID(), nil
 }
 
-// ResizeTerminal resizes the terminal
-func (a *App) ResizeTerminal(rows, cols int) error {
-	if a.terminal == nil {
-		return fmt.Errorf("terminal not initialized")
+// player returns the playback backend of a session
+func (a *App) player(sessionID string) (*terminal.Player, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
 	}
-	return a.terminal.Resize(rows, cols)
+	player, ok := session.Backend().(*terminal.Player)
+	if !ok {
+		return nil, fmt.Errorf("session %s is not a playback session", sessionID)
+	}
+	return player, nil
+}
+
+// PlaybackPlay resumes a playback session
+func (a *App) PlaybackPlay(sessionID string) error {
+	player, err := a.player(sessionID)
+	if err != nil {
+		return err
+	}
+	player.Play()
+	return nil
+}
+
+// PlaybackPause pauses a playback session
+func (a *App) PlaybackPause(sessionID string) error {
+	player, err := a.player(sessionID)
+	if err != nil {
+		return err
+	}
+	player.Pause()
+	return nil
+}
+
+// PlaybackSeek jumps to a position in seconds
+func (a *App) PlaybackSeek(sessionID string, position float64) error {
+	player, err := a.player(sessionID)
+	if err != nil {
+		return err
+	}
+	player.Seek(position)
+	return nil
 }
 
-// GetShell returns the detected shell
-func (a *App) GetShell() string {
-	if a.terminal == nil {
+// PlaybackSetSpeed changes the playback rate of a session
+func (a *App) PlaybackSetSpeed(sessionID string, speed float64) error {
+	player, err := a.player(sessionID)
+	if err != nil {
+		return err
+	}
+	return player.SetSpeed(speed)
+}
+
+// GetPlaybackStatus returns the position and state of a playback session
+func (a *App) GetPlaybackStatus(sessionID string) (terminal.PlaybackStatus, error) {
+	player, err := a.player(sessionID)
+	if err != nil {
+		return terminal.PlaybackStatus{}, err
+	}
+	return player.Status(), nil
+}
+
+// ResizeTerminal resizes a terminal session
+func (a *App) ResizeTerminal(sessionID string, rows, cols int) error {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	return session.Resize(rows, cols)
+}
+
+// GetShell returns the shell running in a session
+func (a *App) GetShell(sessionID string) string {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
 		return "bash" // Default
 	}
-	return a.terminal.GetShell()
+	return session.GetShell()
 }
 
 // GetAvailableShells returns all installed shells on the system
@@ -229,23 +941,11 @@ func (a *App) GetAvailableShells() []terminal.ShellInfo {
 	return terminal.GetAvailableShells()
 }
 
-// RestartTerminalWithShell restarts the terminal with a specific shell
-func (a *App) RestartTerminalWithShell(shellPath string) error {
-	// Close current terminal
-	if a.terminal != nil {
-		a.terminal.Close()
-	}
-
-	// Create new terminal with selected shell
-	newTerminal, err := terminal.NewPTYSessionWithShell(shellPath)
-	if err != nil {
+// RestartTerminalWithShell restarts a session with a specific shell, keeping its ID
+func (a *App) RestartTerminalWithShell(sessionID, shellPath string) error {
+	// The old session's reader exits before the new shell starts
+	if _, err := a.sessions.Replace(sessionID, shellPath); err != nil {
 		return fmt.Errorf("failed to start terminal with %s: %w", shellPath, err)
 	}
-
-	a.terminal = newTerminal
-	
-	// Restart output reader
-	go a.readTerminalOutput()
-	
 	return nil
 }
@@ -17,29 +17,128 @@ const (
 
 // Settings represents user configuration
 type Settings struct {
+	// Provider is the AI API: "openai" for LiteLLM and other OpenAI-compatible
+	// endpoints, "ollama" or "anthropic". Empty means "openai".
+	Provider string `json:"provider"`
+	// LiteLLMEndpoint is the provider's base URL. The openai provider needs
+	// one; the others fall back to their public default when it is empty.
 	LiteLLMEndpoint string `json:"litellm_endpoint"`
-	VirtualKey      string `json:"-"` // Not stored in JSON, use keyring
-	Model           string `json:"model"`
+	VirtualKey      string `json:"-"`     // Not stored in JSON, use keyring
+	Model           string `json:"model"` // empty uses the client's default
 	Theme           string `json:"theme"`
 	FontSize        int    `json:"font_size"`
 	FontFamily      string `json:"font_family"`
 	CursorStyle     string `json:"cursor_style"`
 	AIShortcut      string `json:"ai_shortcut"`
 	SafetyMode      string `json:"safety_mode"` // strict, normal, off
+	// ShellIntegration injects prompt hooks into bash, zsh and fish so the
+	// app can track commands and the working directory
+	ShellIntegration bool `json:"shell_integration"`
+	// Profiles are the named shell configurations new sessions launch from
+	Profiles []ShellProfile `json:"profiles"`
+	// DefaultProfile names the profile used when none is chosen
+	DefaultProfile string `json:"default_profile"`
+	// SessionDaemon runs local shells in a background daemon so they
+	// survive the app restarting or crashing
+	SessionDaemon bool `json:"session_daemon"`
+	// RestoreSessions saves open sessions on exit and offers to recreate
+	// them on the next start
+	RestoreSessions bool `json:"restore_sessions"`
 }
 
+// ShellProfile describes how to start a shell
+type ShellProfile struct {
+	Name string `json:"name"`
+	// Shell is the executable; empty uses the detected default shell
+	Shell string   `json:"shell"`
+	Args  []string `json:"args,omitempty"`
+	Login bool     `json:"login"`
+	// Dir is the starting directory; a leading ~ is the home directory
+	Dir string `json:"dir,omitempty"`
+	// Env adds or overrides environment variables
+	Env map[string]string `json:"env,omitempty"`
+	// UnsetEnv removes inherited environment variables
+	UnsetEnv        []string `json:"unset_env,omitempty"`
+	StartupCommands []string `json:"startup_commands,omitempty"`
+	// Integration overrides ShellIntegration for this profile when set
+	Integration *bool `json:"integration,omitempty"`
+}
+
+// defaultProfileName names the profile created with default settings
+const defaultProfileName = "Default"
+
 // DefaultSettings returns default configuration
 func DefaultSettings() *Settings {
 	return &Settings{
-		LiteLLMEndpoint: "",
-		Model:           "qwen3-terminal",
-		Theme:           "dark",
-		FontSize:        14,
-		FontFamily:      "JetBrains Mono",
-		CursorStyle:     "block",
-		AIShortcut:      "ctrl+k",
-		SafetyMode:      "normal",
+		Provider:         "openai",
+		LiteLLMEndpoint:  "",
+		Model:            "qwen3-terminal",
+		Theme:            "dark",
+		FontSize:         14,
+		FontFamily:       "JetBrains Mono",
+		CursorStyle:      "block",
+		AIShortcut:       "ctrl+k",
+		SafetyMode:       "normal",
+		ShellIntegration: true,
+		Profiles: []ShellProfile{
+			{Name: defaultProfileName, Login: true},
+		},
+		DefaultProfile:  defaultProfileName,
+		RestoreSessions: true,
+	}
+}
+
+// Profile returns the named shell profile. An empty name selects the
+// default profile, or a plain login shell if no profiles are configured.
+func (s *Settings) Profile(name string) (ShellProfile, error) {
+	if name == "" {
+		name = s.DefaultProfile
+		if name == "" && len(s.Profiles) > 0 {
+			name = s.Profiles[0].Name
+		}
+		if name == "" {
+			return ShellProfile{Name: defaultProfileName, Login: true}, nil
+		}
+	}
+	for _, profile := range s.Profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return ShellProfile{}, fmt.Errorf("shell profile %q not found", name)
+}
+
+// IntegrationEnabled reports whether shell integration applies to the profile
+func (s *Settings) IntegrationEnabled(profile ShellProfile) bool {
+	if profile.Integration != nil {
+		return *profile.Integration
 	}
+	return s.ShellIntegration
+}
+
+// Validate checks that the AI provider is known, that profile names are
+// present and unique and that the default profile exists
+func (s *Settings) Validate() error {
+	switch s.Provider {
+	case "", "openai", "ollama", "anthropic":
+	default:
+		return fmt.Errorf("unknown AI provider %q", s.Provider)
+	}
+
+	names := make(map[string]bool, len(s.Profiles))
+	for _, profile := range s.Profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("shell profile name is required")
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("duplicate shell profile %q", profile.Name)
+		}
+		names[profile.Name] = true
+	}
+	if s.DefaultProfile != "" && !names[s.DefaultProfile] {
+		return fmt.Errorf("default shell profile %q not found", s.DefaultProfile)
+	}
+	return nil
 }
 
 // Load reads settings from disk
@@ -75,6 +174,10 @@ func Load() (*Settings, error) {
 
 // Save writes settings to disk
 func (s *Settings) Save() error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
 	configPath, err := getConfigPath()
 	if err != nil {
 		return err
@@ -1,12 +1,66 @@
-import { useState, useCallback } from 'react'
+import { useState, useCallback, useEffect } from 'react'
 import { Terminal } from './components/Terminal'
 import { AIModal } from './components/AIModal'
 import { Settings } from './components/Settings'
+import { RestorePrompt, RestorableSession } from './components/RestorePrompt'
 import { Settings as SettingsIcon, Terminal as TerminalIcon } from 'lucide-react'
 
+const {
+  CreateSession,
+  GetRestorableSessions,
+  RestoreSessions,
+  DiscardRestorableSessions,
+} = window.go.main.App;
+
 function App() {
   const [showSettings, setShowSettings] = useState(false)
   const [showAIModal, setShowAIModal] = useState(false)
+  const [sessionId, setSessionId] = useState<string | null>(null)
+  const [sessionError, setSessionError] = useState<string | null>(null)
+  const [restorable, setRestorable] = useState<RestorableSession[]>([])
+
+  // Start the initial terminal session with the default shell
+  const startSession = useCallback(() => {
+    return CreateSession('')
+      .then(setSessionId)
+      .catch((err: Error) => setSessionError(String(err)))
+  }, [])
+
+  // Offer to restore the last run's sessions before starting a new one
+  useEffect(() => {
+    GetRestorableSessions()
+      .then((sessions: RestorableSession[]) => {
+        if (sessions.length > 0) {
+          setRestorable(sessions)
+        } else {
+          startSession()
+        }
+      })
+      .catch(() => startSession())
+  }, [startSession])
+
+  const handleRestore = useCallback(async () => {
+    try {
+      const sessions = await RestoreSessions()
+      setRestorable([])
+      if (sessions.length > 0) {
+        setSessionId(sessions[0].id)
+      } else {
+        await startSession()
+      }
+    } catch (err) {
+      // Fall back to a fresh shell so the window is never left empty
+      setRestorable([])
+      setSessionError(String(err))
+      await startSession()
+    }
+  }, [startSession])
+
+  const handleDiscard = useCallback(async () => {
+    await DiscardRestorableSessions().catch(console.error)
+    setRestorable([])
+    await startSession()
+  }, [startSession])
 
   const handleOpenAI = useCallback(() => {
     setShowAIModal(true)
@@ -42,7 +96,10 @@ function App() {
 
       {/* Main Content */}
       <div className="flex-1 relative">
-        <Terminal onOpenAI={handleOpenAI} />
+        {sessionId && <Terminal sessionId={sessionId} onOpenAI={handleOpenAI} />}
+        {sessionError && (
+          <div className="p-4 text-red-400">{sessionError}</div>
+        )}
         
         {showAIModal && (
           <AIModal
@@ -54,6 +111,14 @@ function App() {
         {showSettings && (
           <Settings onClose={() => setShowSettings(false)} />
         )}
+
+        {restorable.length > 0 && (
+          <RestorePrompt
+            sessions={restorable}
+            onRestore={handleRestore}
+            onDiscard={handleDiscard}
+          />
+        )}
       </div>
 
       {/* Status Bar */}
@@ -0,0 +1,72 @@
+import React, { useState } from 'react'
+import { History } from 'lucide-react'
+
+export interface RestorableSession {
+  profile?: string
+  shell: string
+  dir?: string
+  title?: string
+  saved_at: string
+}
+
+interface RestorePromptProps {
+  sessions: RestorableSession[]
+  onRestore: () => Promise<void>
+  onDiscard: () => Promise<void>
+}
+
+// Offers to reopen the sessions that were open when the app last exited
+export const RestorePrompt: React.FC<RestorePromptProps> = ({ sessions, onRestore, onDiscard }) => {
+  const [busy, setBusy] = useState(false)
+
+  const handle = (action: () => Promise<void>) => () => {
+    setBusy(true)
+    action().finally(() => setBusy(false))
+  }
+
+  return (
+    <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50">
+      <div className="bg-gray-800 rounded-lg w-full max-w-lg m-4 p-6 shadow-2xl border border-gray-700">
+        {/* Header */}
+        <div className="flex items-center gap-2 mb-4">
+          <History className="w-5 h-5 text-blue-400" />
+          <h2 className="text-xl font-semibold text-white">Restore Sessions</h2>
+        </div>
+
+        <p className="text-sm text-gray-400 mb-3">
+          {sessions.length === 1
+            ? 'A session was open when AI Terminal Pro last exited.'
+            : `${sessions.length} sessions were open when AI Terminal Pro last exited.`}
+        </p>
+
+        {/* Saved sessions */}
+        <ul className="mb-4 space-y-2 max-h-60 overflow-y-auto">
+          {sessions.map((session, i) => (
+            <li key={i} className="bg-gray-900 border border-gray-700 rounded-lg p-3 text-sm">
+              <div className="text-white">{session.title || session.profile || session.shell}</div>
+              {session.dir && <div className="text-gray-500 font-mono text-xs">{session.dir}</div>}
+            </li>
+          ))}
+        </ul>
+
+        {/* Action Buttons */}
+        <div className="flex gap-3">
+          <button
+            onClick={handle(onDiscard)}
+            disabled={busy}
+            className="flex-1 py-2 px-4 bg-gray-700 hover:bg-gray-600 disabled:bg-gray-700 text-white rounded-lg font-medium transition-colors"
+          >
+            Start Fresh
+          </button>
+          <button
+            onClick={handle(onRestore)}
+            disabled={busy}
+            className="flex-1 py-2 px-4 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-700 text-white rounded-lg font-medium transition-colors"
+          >
+            Restore
+          </button>
+        </div>
+      </div>
+    </div>
+  )
+}
@@ -4,13 +4,30 @@ import { FitAddon } from 'xterm-addon-fit'
 import 'xterm/css/xterm.css'
 // Wails runtime bindings - loaded from generated files at runtime
 const { EventsOn, EventsOff } = window.runtime;
-const { WriteToTerminal, ResizeTerminal } = window.go.main.App;
+const {
+  WriteToTerminal,
+  ResizeTerminal,
+  AckTerminalOutput,
+  ResetTerminalOutput,
+  SetOutputEncoding,
+} = window.go.main.App;
+
+// Decode a base64 frame into the exact bytes the shell wrote
+const decodeBase64 = (data: string): Uint8Array => {
+  const binary = atob(data)
+  const bytes = new Uint8Array(binary.length)
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i)
+  }
+  return bytes
+}
 
 interface TerminalProps {
+  sessionId: string
   onOpenAI: () => void
 }
 
-export const Terminal: React.FC<TerminalProps> = ({ onOpenAI }) => {
+export const Terminal: React.FC<TerminalProps> = ({ sessionId, onOpenAI }) => {
   const terminalRef = useRef<HTMLDivElement>(null)
   const xtermRef = useRef<XTerm | null>(null)
   const fitAddonRef = useRef<FitAddon | null>(null)
@@ -47,7 +64,7 @@ export const Terminal: React.FC<TerminalProps> = ({ onOpenAI }) => {
 
     // Handle user input - send to backend PTY
     term.onData((data) => {
-      WriteToTerminal(data).catch((err: Error) => {
+      WriteToTerminal(sessionId, data).catch((err: Error) => {
         console.error('Failed to write to terminal:', err)
       })
     })
@@ -62,16 +79,54 @@ export const Terminal: React.FC<TerminalProps> = ({ onOpenAI }) => {
       return true
     })
 
+    // Recordings resize the terminal in-band with CSI 8;rows;cols t. Report
+    // the new size back so the session agrees with what is displayed.
+    term.parser.registerCsiHandler({ final: 't' }, (params) => {
+      if (params[0] !== 8) return false
+      const rows = typeof params[1] === 'number' && params[1] > 0 ? params[1] : term.rows
+      const cols = typeof params[2] === 'number' && params[2] > 0 ? params[2] : term.cols
+      term.resize(cols, rows)
+      ResizeTerminal(sessionId, rows, cols).catch(console.error)
+      return true
+    })
+
     xtermRef.current = term
     fitAddonRef.current = fitAddon
 
     // Initial message
-    term.writeln('\x1b[1;34mAI Terminal Pro\x1b[0m - Press Ctrl+K for AI mode')
-    term.writeln('')
+    const writeBanner = () => {
+      term.writeln('\x1b[1;34mAI Terminal Pro\x1b[0m - Press Ctrl+K for AI mode')
+      term.writeln('')
+    }
+    writeBanner()
+
+    // Listen for this session's output from backend
+    const outputEvent = `terminal-output:${sessionId}`
+    EventsOn(outputEvent, (frame: { seq: number; data: string; encoding: string; replay?: boolean }) => {
+      const data = frame.encoding === 'base64' ? decodeBase64(frame.data) : frame.data
+      // A replay repeats everything the session has output, including any
+      // frames that arrived before it
+      if (frame.replay) {
+        term.reset()
+        writeBanner()
+      }
+      // Acknowledge once xterm has parsed the frame so the backend keeps reading
+      term.write(data, () => {
+        AckTerminalOutput(sessionId, frame.seq).catch(console.error)
+      })
+    })
+    // Raw bytes let xterm's own decoder handle UTF-8 and binary output
+    SetOutputEncoding(sessionId, 'base64').catch(console.error)
+    // Frames sent before we subscribed were never shown or acknowledged, so
+    // the backend replays the session's output
+    ResetTerminalOutput(sessionId).catch(console.error)
 
-    // Listen for terminal output from backend
-    EventsOn('terminal-output', (data: string) => {
-      term.write(data)
+    // Report when the shell exits
+    const exitedEvent = `terminal-exited:${sessionId}`
+    EventsOn(exitedEvent, (status: { code: number; signal?: string }) => {
+      const reason = status.signal ? `signal ${status.signal}` : `code ${status.code}`
+      term.writeln('')
+      term.writeln(`\x1b[2m[Process exited with ${reason}]\x1b[0m`)
     })
 
     // Handle resize
@@ -79,7 +134,7 @@ export const Terminal: React.FC<TerminalProps> = ({ onOpenAI }) => {
       fitAddon.fit()
       const dims = fitAddon.proposeDimensions()
       if (dims) {
-        ResizeTerminal(Math.floor(dims.rows), Math.floor(dims.cols)).catch(console.error)
+        ResizeTerminal(sessionId, Math.floor(dims.rows), Math.floor(dims.cols)).catch(console.error)
       }
     }
 
@@ -90,10 +145,11 @@ export const Terminal: React.FC<TerminalProps> = ({ onOpenAI }) => {
 
     return () => {
       window.removeEventListener('resize', handleResize)
-      EventsOff('terminal-output')
+      EventsOff(outputEvent)
+      EventsOff(exitedEvent)
       term.dispose()
     }
-  }, [onOpenAI])
+  }, [sessionId, onOpenAI])
 
   return (
     <div 
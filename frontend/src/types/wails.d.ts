@@ -10,9 +10,18 @@ declare global {
     go: {
       main: {
         App: {
-          WriteToTerminal: (data: string) => Promise<void>;
-          ResizeTerminal: (rows: number, cols: number) => Promise<void>;
-          GenerateCommand: (description: string) => Promise<Record<string, any>>;
+          CreateSession: (shellPath: string) => Promise<string>;
+          CloseSession: (sessionId: string) => Promise<void>;
+          ListSessions: () => Promise<any[]>;
+          WriteToTerminal: (sessionId: string, data: string) => Promise<void>;
+          ResizeTerminal: (sessionId: string, rows: number, cols: number) => Promise<void>;
+          AckTerminalOutput: (sessionId: string, seq: number) => Promise<void>;
+          ResetTerminalOutput: (sessionId: string) => Promise<void>;
+          SetOutputEncoding: (sessionId: string, encoding: string) => Promise<void>;
+          GetRestorableSessions: () => Promise<any[]>;
+          RestoreSessions: () => Promise<any[]>;
+          DiscardRestorableSessions: () => Promise<void>;
+          GenerateCommand: (sessionId: string, description: string) => Promise<Record<string, any>>;
           ValidateCommand: (command: string) => Promise<Record<string, any>>;
           GetSettings: () => Promise<any>;
           SaveSettings: (settings: any) => Promise<void>;
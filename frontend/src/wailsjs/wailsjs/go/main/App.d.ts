@@ -1,23 +1,114 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {terminal} from '../models';
 import {config} from '../models';
+import {main} from '../models';
+import {ai} from '../models';
 
-export function GenerateCommand(arg1:string):Promise<Record<string, any>>;
+export function AckTerminalOutput(arg1:string,arg2:number):Promise<void>;
+
+export function AddToBroadcastGroup(arg1:string,arg2:string):Promise<void>;
+
+export function AttachDaemonSessions():Promise<Array<terminal.SessionInfo>>;
+
+export function AttachTmux(arg1:string):Promise<void>;
+
+export function CloseSession(arg1:string):Promise<terminal.ShutdownReport>;
+
+export function CreateBroadcastGroup(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function CreateSSHSession(arg1:terminal.SSHConfig):Promise<string>;
+
+export function CreateSession(arg1:string):Promise<string>;
+
+export function CreateSessionFromProfile(arg1:string):Promise<string>;
+
+export function CreateSessionFromSSHProfile(arg1:string):Promise<string>;
+
+export function DeleteBroadcastGroup(arg1:string):Promise<void>;
+
+export function DetachTmux(arg1:string):Promise<void>;
+
+export function DiscardRestorableSessions():Promise<void>;
+
+export function GenerateCommand(arg1:string,arg2:string):Promise<Record<string, any>>;
+
+export function GetAvailableShells():Promise<Array<terminal.ShellInfo>>;
+
+export function GetCommandHistory(arg1:string):Promise<Array<terminal.CommandRecord>>;
+
+export function GetForegroundProcess(arg1:string):Promise<terminal.ForegroundProcess>;
+
+export function GetLastCommand(arg1:string):Promise<terminal.CommandRecord>;
 
 export function GetOS():Promise<string>;
 
+export function GetPlaybackStatus(arg1:string):Promise<terminal.PlaybackStatus>;
+
+export function GetProfiles():Promise<Array<config.ShellProfile>>;
+
+export function GetRestorableSessions():Promise<Array<main.RestorableSession>>;
+
+export function GetSSHProfiles():Promise<Array<terminal.SSHProfile>>;
+
+export function GetScreenSnapshot(arg1:string,arg2:boolean):Promise<terminal.ScreenSnapshot>;
+
+export function GetScrollbackLines(arg1:string,arg2:number,arg3:number):Promise<terminal.LineRange>;
+
+export function GetScrollbackRaw(arg1:string):Promise<string>;
+
 export function GetSettings():Promise<config.Settings>;
 
-export function GetShell():Promise<string>;
+export function GetShell(arg1:string):Promise<string>;
+
+export function GetWorkingDir(arg1:string):Promise<terminal.WorkingDir>;
 
 export function Greet(arg1:string):Promise<string>;
 
-export function ReadFromTerminal():Promise<string>;
+export function IsSessionBusy(arg1:string):Promise<boolean>;
+
+export function ListBroadcastGroups():Promise<Array<terminal.BroadcastGroupInfo>>;
+
+export function ListModels():Promise<Array<ai.Model>>;
+
+export function ListRecordings():Promise<Array<main.RecordingInfo>>;
+
+export function ListSessions():Promise<Array<terminal.SessionInfo>>;
+
+export function ListTmuxSessions():Promise<Array<terminal.TmuxSessionInfo>>;
+
+export function OpenRecording(arg1:string,arg2:number,arg3:number):Promise<string>;
+
+export function PlaybackPause(arg1:string):Promise<void>;
+
+export function PlaybackPlay(arg1:string):Promise<void>;
 
-export function ResizeTerminal(arg1:number,arg2:number):Promise<void>;
+export function PlaybackSeek(arg1:string,arg2:number):Promise<void>;
+
+export function PlaybackSetSpeed(arg1:string,arg2:number):Promise<void>;
+
+export function RemoveFromBroadcastGroup(arg1:string,arg2:string):Promise<void>;
+
+export function ResetTerminalOutput(arg1:string):Promise<void>;
+
+export function ResizeTerminal(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function RestartTerminalWithShell(arg1:string,arg2:string):Promise<void>;
+
+export function RestoreSessions():Promise<Array<terminal.SessionInfo>>;
 
 export function SaveSettings(arg1:config.Settings):Promise<void>;
 
+export function SearchScrollback(arg1:string,arg2:string,arg3:terminal.SearchOptions):Promise<Array<terminal.Match>>;
+
+export function SendSignal(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetOutputEncoding(arg1:string,arg2:string):Promise<void>;
+
+export function StartRecording(arg1:string,arg2:boolean):Promise<string>;
+
+export function StopRecording(arg1:string):Promise<string>;
+
 export function ValidateCommand(arg1:string):Promise<Record<string, any>>;
 
-export function WriteToTerminal(arg1:string):Promise<void>;
+export function WriteToTerminal(arg1:string,arg2:string):Promise<void>;
@@ -2,42 +2,218 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function GenerateCommand(arg1) {
-  return window['go']['main']['App']['GenerateCommand'](arg1);
+export function AckTerminalOutput(arg1, arg2) {
+  return window['go']['main']['App']['AckTerminalOutput'](arg1, arg2);
+}
+
+export function AddToBroadcastGroup(arg1, arg2) {
+  return window['go']['main']['App']['AddToBroadcastGroup'](arg1, arg2);
+}
+
+export function AttachDaemonSessions() {
+  return window['go']['main']['App']['AttachDaemonSessions']();
+}
+
+export function AttachTmux(arg1) {
+  return window['go']['main']['App']['AttachTmux'](arg1);
+}
+
+export function CloseSession(arg1) {
+  return window['go']['main']['App']['CloseSession'](arg1);
+}
+
+export function CreateBroadcastGroup(arg1, arg2) {
+  return window['go']['main']['App']['CreateBroadcastGroup'](arg1, arg2);
+}
+
+export function CreateSSHSession(arg1) {
+  return window['go']['main']['App']['CreateSSHSession'](arg1);
+}
+
+export function CreateSession(arg1) {
+  return window['go']['main']['App']['CreateSession'](arg1);
+}
+
+export function CreateSessionFromProfile(arg1) {
+  return window['go']['main']['App']['CreateSessionFromProfile'](arg1);
+}
+
+export function CreateSessionFromSSHProfile(arg1) {
+  return window['go']['main']['App']['CreateSessionFromSSHProfile'](arg1);
+}
+
+export function DeleteBroadcastGroup(arg1) {
+  return window['go']['main']['App']['DeleteBroadcastGroup'](arg1);
+}
+
+export function DetachTmux(arg1) {
+  return window['go']['main']['App']['DetachTmux'](arg1);
+}
+
+export function DiscardRestorableSessions() {
+  return window['go']['main']['App']['DiscardRestorableSessions']();
+}
+
+export function GenerateCommand(arg1, arg2) {
+  return window['go']['main']['App']['GenerateCommand'](arg1, arg2);
+}
+
+export function GetAvailableShells() {
+  return window['go']['main']['App']['GetAvailableShells']();
+}
+
+export function GetCommandHistory(arg1) {
+  return window['go']['main']['App']['GetCommandHistory'](arg1);
+}
+
+export function GetForegroundProcess(arg1) {
+  return window['go']['main']['App']['GetForegroundProcess'](arg1);
+}
+
+export function GetLastCommand(arg1) {
+  return window['go']['main']['App']['GetLastCommand'](arg1);
 }
 
 export function GetOS() {
   return window['go']['main']['App']['GetOS']();
 }
 
+export function GetPlaybackStatus(arg1) {
+  return window['go']['main']['App']['GetPlaybackStatus'](arg1);
+}
+
+export function GetProfiles() {
+  return window['go']['main']['App']['GetProfiles']();
+}
+
+export function GetRestorableSessions() {
+  return window['go']['main']['App']['GetRestorableSessions']();
+}
+
+export function GetSSHProfiles() {
+  return window['go']['main']['App']['GetSSHProfiles']();
+}
+
+export function GetScreenSnapshot(arg1, arg2) {
+  return window['go']['main']['App']['GetScreenSnapshot'](arg1, arg2);
+}
+
+export function GetScrollbackLines(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetScrollbackLines'](arg1, arg2, arg3);
+}
+
+export function GetScrollbackRaw(arg1) {
+  return window['go']['main']['App']['GetScrollbackRaw'](arg1);
+}
+
 export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
 
-export function GetShell() {
-  return window['go']['main']['App']['GetShell']();
+export function GetShell(arg1) {
+  return window['go']['main']['App']['GetShell'](arg1);
+}
+
+export function GetWorkingDir(arg1) {
+  return window['go']['main']['App']['GetWorkingDir'](arg1);
 }
 
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
-export function ReadFromTerminal() {
-  return window['go']['main']['App']['ReadFromTerminal']();
+export function IsSessionBusy(arg1) {
+  return window['go']['main']['App']['IsSessionBusy'](arg1);
+}
+
+export function ListBroadcastGroups() {
+  return window['go']['main']['App']['ListBroadcastGroups']();
+}
+
+export function ListModels() {
+  return window['go']['main']['App']['ListModels']();
+}
+
+export function ListRecordings() {
+  return window['go']['main']['App']['ListRecordings']();
+}
+
+export function ListSessions() {
+  return window['go']['main']['App']['ListSessions']();
+}
+
+export function ListTmuxSessions() {
+  return window['go']['main']['App']['ListTmuxSessions']();
+}
+
+export function OpenRecording(arg1, arg2, arg3) {
+  return window['go']['main']['App']['OpenRecording'](arg1, arg2, arg3);
+}
+
+export function PlaybackPause(arg1) {
+  return window['go']['main']['App']['PlaybackPause'](arg1);
+}
+
+export function PlaybackPlay(arg1) {
+  return window['go']['main']['App']['PlaybackPlay'](arg1);
 }
 
-export function ResizeTerminal(arg1, arg2) {
-  return window['go']['main']['App']['ResizeTerminal'](arg1, arg2);
+export function PlaybackSeek(arg1, arg2) {
+  return window['go']['main']['App']['PlaybackSeek'](arg1, arg2);
+}
+
+export function PlaybackSetSpeed(arg1, arg2) {
+  return window['go']['main']['App']['PlaybackSetSpeed'](arg1, arg2);
+}
+
+export function RemoveFromBroadcastGroup(arg1, arg2) {
+  return window['go']['main']['App']['RemoveFromBroadcastGroup'](arg1, arg2);
+}
+
+export function ResetTerminalOutput(arg1) {
+  return window['go']['main']['App']['ResetTerminalOutput'](arg1);
+}
+
+export function ResizeTerminal(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ResizeTerminal'](arg1, arg2, arg3);
+}
+
+export function RestartTerminalWithShell(arg1, arg2) {
+  return window['go']['main']['App']['RestartTerminalWithShell'](arg1, arg2);
+}
+
+export function RestoreSessions() {
+  return window['go']['main']['App']['RestoreSessions']();
 }
 
 export function SaveSettings(arg1) {
   return window['go']['main']['App']['SaveSettings'](arg1);
 }
 
+export function SearchScrollback(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SearchScrollback'](arg1, arg2, arg3);
+}
+
+export function SendSignal(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SendSignal'](arg1, arg2, arg3);
+}
+
+export function SetOutputEncoding(arg1, arg2) {
+  return window['go']['main']['App']['SetOutputEncoding'](arg1, arg2);
+}
+
+export function StartRecording(arg1, arg2) {
+  return window['go']['main']['App']['StartRecording'](arg1, arg2);
+}
+
+export function StopRecording(arg1) {
+  return window['go']['main']['App']['StopRecording'](arg1);
+}
+
 export function ValidateCommand(arg1) {
   return window['go']['main']['App']['ValidateCommand'](arg1);
 }
 
-export function WriteToTerminal(arg1) {
-  return window['go']['main']['App']['WriteToTerminal'](arg1);
+export function WriteToTerminal(arg1, arg2) {
+  return window['go']['main']['App']['WriteToTerminal'](arg1, arg2);
 }
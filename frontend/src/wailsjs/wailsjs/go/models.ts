@@ -1,6 +1,54 @@
+export namespace ai {
+	
+	export class Model {
+	    id: string;
+	    owned_by?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Model(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.owned_by = source["owned_by"];
+	    }
+	}
+
+}
+
 export namespace config {
 	
+	export class ShellProfile {
+	    name: string;
+	    shell: string;
+	    args?: string[];
+	    login: boolean;
+	    dir?: string;
+	    env?: Record<string, string>;
+	    unset_env?: string[];
+	    startup_commands?: string[];
+	    integration?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShellProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.shell = source["shell"];
+	        this.args = source["args"];
+	        this.login = source["login"];
+	        this.dir = source["dir"];
+	        this.env = source["env"];
+	        this.unset_env = source["unset_env"];
+	        this.startup_commands = source["startup_commands"];
+	        this.integration = source["integration"];
+	    }
+	}
 	export class Settings {
+	    provider: string;
 	    litellm_endpoint: string;
 	    model: string;
 	    theme: string;
@@ -9,6 +57,11 @@ export namespace config {
 	    cursor_style: string;
 	    ai_shortcut: string;
 	    safety_mode: string;
+	    shell_integration: boolean;
+	    profiles: ShellProfile[];
+	    default_profile: string;
+	    session_daemon: boolean;
+	    restore_sessions: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new Settings(source);
@@ -16,6 +69,7 @@ export namespace config {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
 	        this.litellm_endpoint = source["litellm_endpoint"];
 	        this.model = source["model"];
 	        this.theme = source["theme"];
@@ -24,6 +78,602 @@ export namespace config {
 	        this.cursor_style = source["cursor_style"];
 	        this.ai_shortcut = source["ai_shortcut"];
 	        this.safety_mode = source["safety_mode"];
+	        this.shell_integration = source["shell_integration"];
+	        this.profiles = this.convertValues(source["profiles"], ShellProfile);
+	        this.default_profile = source["default_profile"];
+	        this.session_daemon = source["session_daemon"];
+	        this.restore_sessions = source["restore_sessions"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class RecordingInfo {
+	    name: string;
+	    path: string;
+	    size: number;
+	    // Go type: time
+	    mod_time: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.mod_time = this.convertValues(source["mod_time"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RestorableSession {
+	    profile?: string;
+	    shell: string;
+	    dir?: string;
+	    title?: string;
+	    // Go type: time
+	    saved_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RestorableSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.profile = source["profile"];
+	        this.shell = source["shell"];
+	        this.dir = source["dir"];
+	        this.title = source["title"];
+	        this.saved_at = this.convertValues(source["saved_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace terminal {
+	
+	export class BroadcastGroupInfo {
+	    name: string;
+	    sessions: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BroadcastGroupInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.sessions = source["sessions"];
+	    }
+	}
+	export class CommandRecord {
+	    id: number;
+	    command: string;
+	    prompt_offset: number;
+	    start_offset: number;
+	    end_offset: number;
+	    prompt_line: number;
+	    start_line: number;
+	    end_line: number;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    finished_at?: any;
+	    duration_ms: number;
+	    exit_code?: number;
+	    running: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommandRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.command = source["command"];
+	        this.prompt_offset = source["prompt_offset"];
+	        this.start_offset = source["start_offset"];
+	        this.end_offset = source["end_offset"];
+	        this.prompt_line = source["prompt_line"];
+	        this.start_line = source["start_line"];
+	        this.end_line = source["end_line"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.finished_at = this.convertValues(source["finished_at"], null);
+	        this.duration_ms = source["duration_ms"];
+	        this.exit_code = source["exit_code"];
+	        this.running = source["running"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExitStatus {
+	    code: number;
+	    signal?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExitStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.signal = source["signal"];
+	    }
+	}
+	export class ForegroundProcess {
+	    pid: number;
+	    name: string;
+	    args: string[];
+	    // Go type: time
+	    started_at: any;
+	    elapsed_ms: number;
+	    shell: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForegroundProcess(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.name = source["name"];
+	        this.args = source["args"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.elapsed_ms = source["elapsed_ms"];
+	        this.shell = source["shell"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LineRange {
+	    first: number;
+	    oldest: number;
+	    total: number;
+	    lines: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LineRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.first = source["first"];
+	        this.oldest = source["oldest"];
+	        this.total = source["total"];
+	        this.lines = source["lines"];
+	    }
+	}
+	export class Match {
+	    line: number;
+	    start: number;
+	    end: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Match(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.text = source["text"];
+	    }
+	}
+	export class PlaybackStatus {
+	    position: number;
+	    duration: number;
+	    playing: boolean;
+	    speed: number;
+	    finished: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaybackStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.position = source["position"];
+	        this.duration = source["duration"];
+	        this.playing = source["playing"];
+	        this.speed = source["speed"];
+	        this.finished = source["finished"];
+	    }
+	}
+	export class SSHConfig {
+	    host: string;
+	    port?: number;
+	    user?: string;
+	    identity_files?: string[];
+	    password?: string;
+	    use_agent: boolean;
+	    known_hosts_file?: string;
+	    accept_new_host_key: boolean;
+	    proxy_jump?: string;
+	    jumps?: SSHConfig[];
+	    rows?: number;
+	    cols?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.identity_files = source["identity_files"];
+	        this.password = source["password"];
+	        this.use_agent = source["use_agent"];
+	        this.known_hosts_file = source["known_hosts_file"];
+	        this.accept_new_host_key = source["accept_new_host_key"];
+	        this.proxy_jump = source["proxy_jump"];
+	        this.jumps = this.convertValues(source["jumps"], SSHConfig);
+	        this.rows = source["rows"];
+	        this.cols = source["cols"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SSHProfile {
+	    name: string;
+	    config: SSHConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.config = this.convertValues(source["config"], SSHConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StyledSpan {
+	    text: string;
+	    fg?: string;
+	    bg?: string;
+	    bold?: boolean;
+	    dim?: boolean;
+	    italic?: boolean;
+	    underline?: boolean;
+	    inverse?: boolean;
+	    strike?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StyledSpan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.fg = source["fg"];
+	        this.bg = source["bg"];
+	        this.bold = source["bold"];
+	        this.dim = source["dim"];
+	        this.italic = source["italic"];
+	        this.underline = source["underline"];
+	        this.inverse = source["inverse"];
+	        this.strike = source["strike"];
+	    }
+	}
+	export class ScreenSnapshot {
+	    rows: number;
+	    cols: number;
+	    cursor_row: number;
+	    cursor_col: number;
+	    cursor_visible: boolean;
+	    alt_screen: boolean;
+	    title: string;
+	    lines: string[];
+	    styled?: StyledSpan[][];
+	
+	    static createFrom(source: any = {}) {
+	        return new ScreenSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rows = source["rows"];
+	        this.cols = source["cols"];
+	        this.cursor_row = source["cursor_row"];
+	        this.cursor_col = source["cursor_col"];
+	        this.cursor_visible = source["cursor_visible"];
+	        this.alt_screen = source["alt_screen"];
+	        this.title = source["title"];
+	        this.lines = source["lines"];
+	        this.styled = this.convertValues(source["styled"], StyledSpan);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SearchOptions {
+	    regex: boolean;
+	    case_sensitive: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.regex = source["regex"];
+	        this.case_sensitive = source["case_sensitive"];
+	    }
+	}
+	export class SessionInfo {
+	    id: string;
+	    shell: string;
+	    kind: string;
+	    profile?: string;
+	    // Go type: time
+	    created_at: any;
+	    running: boolean;
+	    exit?: ExitStatus;
+	    foreground?: ForegroundProcess;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.shell = source["shell"];
+	        this.kind = source["kind"];
+	        this.profile = source["profile"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.running = source["running"];
+	        this.exit = this.convertValues(source["exit"], ExitStatus);
+	        this.foreground = this.convertValues(source["foreground"], ForegroundProcess);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ShellInfo {
+	    name: string;
+	    path: string;
+	    type: string;
+	    description: string;
+	    version?: string;
+	    dialect: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShellInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.type = source["type"];
+	        this.description = source["description"];
+	        this.version = source["version"];
+	        this.dialect = source["dialect"];
+	    }
+	}
+	export class ShutdownReport {
+	    session_id: string;
+	    forced: boolean;
+	    survivors?: ForegroundProcess[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ShutdownReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.session_id = source["session_id"];
+	        this.forced = source["forced"];
+	        this.survivors = this.convertValues(source["survivors"], ForegroundProcess);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TmuxSessionInfo {
+	    name: string;
+	    windows: number;
+	    attached: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TmuxSessionInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.windows = source["windows"];
+	        this.attached = source["attached"];
+	    }
+	}
+	export class WorkingDir {
+	    path: string;
+	    host?: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorkingDir(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.host = source["host"];
+	        this.source = source["source"];
 	    }
 	}
 
@@ -2,6 +2,10 @@ package main
 
 import (
 	"embed"
+	"fmt"
+	"os"
+
+	"ai-terminal-pro/terminal"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,6 +16,15 @@ import (
 var assets embed.FS
 
 func main() {
+	// The same binary serves as the session daemon, started by the app
+	if len(os.Args) > 1 && os.Args[1] == terminal.DaemonFlag {
+		if err := terminal.RunSessionDaemon(terminal.DaemonSocketPath()); err != nil {
+			fmt.Fprintf(os.Stderr, "Session daemon: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
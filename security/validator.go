@@ -38,6 +38,10 @@ func (r RiskLevel) String() string {
 type Validator struct {
 	blockedPatterns []*regexp.Regexp
 	warningPatterns []*regexp.Regexp
+
+	// Patterns for shells whose syntax differs from POSIX, keyed by dialect
+	dialectBlocked map[string][]*regexp.Regexp
+	dialectWarning map[string][]*regexp.Regexp
 }
 
 // NewValidator creates a new command validator
@@ -95,6 +99,78 @@ func (v *Validator) compilePatterns() {
 			v.warningPatterns = append(v.warningPatterns, re)
 		}
 	}
+
+	v.compileDialectPatterns()
+}
+
+// compileDialectPatterns initializes patterns for PowerShell and cmd, which
+// spell dangerous operations differently from POSIX shells
+func (v *Validator) compileDialectPatterns() {
+	blocked := map[string][]string{
+		"powershell": {
+			`(?i)Remove-Item\s+.*-Recurse.*\s[A-Z]:\\?(\s|$)`, // Delete a drive root
+			`(?i)Format-Volume`, // Format filesystem
+			`(?i)Clear-Disk`,    // Wipe disk
+			`(?i)(iwr|irm|Invoke-WebRequest|Invoke-RestMethod).*\|\s*(iex|Invoke-Expression)`, // Pipe download to shell
+			`(?i)(iex|Invoke-Expression)\s*\(.*(DownloadString|iwr|irm)`,                      // Execute downloaded code
+		},
+		"cmd": {
+			`(?i)format\s+[A-Z]:`,                    // Format drive
+			`(?i)(rd|rmdir)\s+/s.*\s[A-Z]:\\?(\s|$)`, // Delete a drive root
+			`(?i)del\s+.*/s.*\s[A-Z]:\\\*?(\s|$)`,    // Delete every file on a drive
+		},
+	}
+	warning := map[string][]string{
+		"powershell": {
+			`(?i)Remove-Item\s+.*-Recurse`,        // Recursive delete
+			`(?i)Set-ExecutionPolicy`,             // Weaken script policy
+			`(?i)Start-Process\s+.*-Verb\s+RunAs`, // Elevated privileges
+			`(?i)\\Windows\\System32`,             // Touch system files
+		},
+		"cmd": {
+			`(?i)(rd|rmdir)\s+/s`,    // Recursive delete
+			`(?i)reg\s+(add|delete)`, // Registry changes
+			`(?i)runas`,              // Elevated privileges
+		},
+	}
+
+	v.dialectBlocked = compileAll(blocked)
+	v.dialectWarning = compileAll(warning)
+}
+
+// compileAll compiles a set of pattern lists, skipping invalid patterns
+func compileAll(patterns map[string][]string) map[string][]*regexp.Regexp {
+	compiled := make(map[string][]*regexp.Regexp, len(patterns))
+	for dialect, list := range patterns {
+		for _, p := range list {
+			if re, err := regexp.Compile(p); err == nil {
+				compiled[dialect] = append(compiled[dialect], re)
+			}
+		}
+	}
+	return compiled
+}
+
+// ValidateCommandFor checks a command written for the given shell dialect,
+// such as "posix", "fish" or "powershell". Dialect-specific patterns are
+// checked in addition to the general ones.
+func (v *Validator) ValidateCommandFor(command, dialect string) RiskLevel {
+	trimmed := strings.TrimSpace(command)
+	for _, pattern := range v.dialectBlocked[dialect] {
+		if pattern.MatchString(trimmed) {
+			return RiskCritical
+		}
+	}
+
+	risk := v.ValidateCommand(command)
+	if risk < RiskMedium {
+		for _, pattern := range v.dialectWarning[dialect] {
+			if pattern.MatchString(trimmed) {
+				return RiskMedium
+			}
+		}
+	}
+	return risk
 }
 
 // ValidateCommand checks a command and returns its risk level
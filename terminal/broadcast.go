@@ -0,0 +1,304 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// BroadcastGroupInfo describes a broadcast group for the frontend
+type BroadcastGroupInfo struct {
+	Name     string   `json:"name"`
+	Sessions []string `json:"sessions"`
+}
+
+// broadcastGroup mirrors input written to any of its sessions to all of
+// them. It also follows the line being typed, so that a command can be
+// checked before the keystroke that submits it is sent.
+type broadcastGroup struct {
+	name     string
+	sessions []string
+
+	mu   sync.Mutex
+	line typedLine
+}
+
+// typedLine is a command line as followed from typed input
+type typedLine struct {
+	text []rune
+	// unknown is set once a key has edited the line in a way that cannot
+	// be followed, such as history recall, completion or moving the cursor
+	unknown bool
+}
+
+// errUncheckedLine refuses to submit a line whose text is not known
+var errUncheckedLine = errors.New("broadcast refused: the command line was edited with keys that cannot be checked, such as arrows or Tab; press Ctrl+C and type it again")
+
+// BroadcastCheck vets a command line before it is submitted to a session.
+// Returning an error stops the input from being sent anywhere.
+type BroadcastCheck func(target *Session, line string) error
+
+// CreateBroadcastGroup creates a group of sessions that receive each
+// other's input. A session belongs to at most one group, so sessions are
+// moved out of any group they were in.
+func (m *SessionManager) CreateBroadcastGroup(name string, sessionIDs []string) error {
+	if name == "" {
+		return fmt.Errorf("broadcast group needs a name")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.groups[name]; ok {
+		return fmt.Errorf("broadcast group %s already exists", name)
+	}
+	for _, id := range sessionIDs {
+		if _, ok := m.sessions[id]; !ok {
+			return fmt.Errorf("session %s not found", id)
+		}
+	}
+	group := &broadcastGroup{name: name}
+	m.groups[name] = group
+	for _, id := range sessionIDs {
+		m.joinGroupLocked(group, id)
+	}
+	return nil
+}
+
+// DeleteBroadcastGroup ends a broadcast group; its sessions go back to
+// receiving only their own input
+func (m *SessionManager) DeleteBroadcastGroup(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.groups[name]; !ok {
+		return fmt.Errorf("broadcast group %s not found", name)
+	}
+	delete(m.groups, name)
+	return nil
+}
+
+// AddToBroadcastGroup moves a session into a broadcast group
+func (m *SessionManager) AddToBroadcastGroup(name, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	group, ok := m.groups[name]
+	if !ok {
+		return fmt.Errorf("broadcast group %s not found", name)
+	}
+	if _, ok := m.sessions[sessionID]; !ok {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	m.joinGroupLocked(group, sessionID)
+	return nil
+}
+
+// RemoveFromBroadcastGroup takes a session out of a broadcast group
+func (m *SessionManager) RemoveFromBroadcastGroup(name, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	group, ok := m.groups[name]
+	if !ok {
+		return fmt.Errorf("broadcast group %s not found", name)
+	}
+	if !containsString(group.sessions, sessionID) {
+		return fmt.Errorf("session %s is not in broadcast group %s", sessionID, name)
+	}
+	m.leaveGroupsLocked(sessionID)
+	return nil
+}
+
+// ListBroadcastGroups returns the broadcast groups ordered by name
+func (m *SessionManager) ListBroadcastGroups() []BroadcastGroupInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]BroadcastGroupInfo, 0, len(m.groups))
+	for _, group := range m.groups {
+		infos = append(infos, BroadcastGroupInfo{
+			Name:     group.name,
+			Sessions: append([]string{}, group.sessions...),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// joinGroupLocked adds a session to a group after removing it from any
+// other. The caller holds m.mu.
+func (m *SessionManager) joinGroupLocked(group *broadcastGroup, sessionID string) {
+	m.leaveGroupsLocked(sessionID)
+	group.sessions = append(group.sessions, sessionID)
+}
+
+// leaveGroupsLocked removes a session from its broadcast group. The caller
+// holds m.mu.
+func (m *SessionManager) leaveGroupsLocked(sessionID string) {
+	for _, group := range m.groups {
+		for i, id := range group.sessions {
+			if id == sessionID {
+				group.sessions = append(group.sessions[:i:i], group.sessions[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Broadcast writes input to a session and to every other session in its
+// broadcast group. If the input submits a command line, check is called
+// for each target first, and nothing is sent if any check fails or the
+// line cannot be known. Sessions outside a group just receive the input,
+// unchecked.
+func (m *SessionManager) Broadcast(sessionID string, data []byte, check BroadcastCheck) error {
+	m.mu.RLock()
+	source, ok := m.sessions[sessionID]
+	var group *broadcastGroup
+	var targets []*Session
+	for _, g := range m.groups {
+		if containsString(g.sessions, sessionID) {
+			group = g
+			for _, id := range g.sessions {
+				// Sessions whose shell has exited stay in the group until
+				// closed, but there is nothing to write to
+				if s, ok := m.sessions[id]; ok && (id == sessionID || !s.hasExited()) {
+					targets = append(targets, s)
+				}
+			}
+			break
+		}
+	}
+	m.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	if group == nil {
+		_, err := source.Write(data)
+		return err
+	}
+
+	group.mu.Lock()
+	defer group.mu.Unlock()
+
+	line, submitted := editLine(group.line, data)
+	if check != nil {
+		for _, command := range submitted {
+			if command.unknown {
+				return errUncheckedLine
+			}
+			for _, target := range targets {
+				if err := check(target, string(command.text)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	group.line = line
+
+	var errs []string
+	for _, target := range targets {
+		if _, err := target.Write(data); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", target.ID(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to write to %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// hasExited reports whether the session's shell has exited
+func (s *Session) hasExited() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exited
+}
+
+// editLine applies typed input to a partial command line, as a shell's line
+// editor would for the common keys. It returns the line left being typed
+// and any lines the input submits. Other keys, such as arrows, Tab and
+// control keys, leave the line unknown until ^C abandons it.
+func editLine(line typedLine, data []byte) (typedLine, []typedLine) {
+	line.text = append([]rune(nil), line.text...)
+	var submitted []typedLine
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		switch r {
+		case '\r', '\n':
+			submitted = append(submitted, typedLine{text: append([]rune(nil), line.text...), unknown: line.unknown})
+			line = typedLine{}
+		case 0x7f, '\b': // backspace
+			if len(line.text) > 0 {
+				line.text = line.text[:len(line.text)-1]
+			}
+		case 0x03: // ^C abandons the line
+			line = typedLine{}
+		case 0x15: // ^U erases the line up to the cursor
+			line.text = line.text[:0]
+		case 0x17: // ^W erases the previous word
+			end := len(line.text)
+			for end > 0 && line.text[end-1] == ' ' {
+				end--
+			}
+			for end > 0 && line.text[end-1] != ' ' {
+				end--
+			}
+			line.text = line.text[:end]
+		case 0x0c: // ^L redraws the screen
+		case 0x1b:
+			rest := skipEscape(data)
+			if !passiveEscape(data[:len(data)-len(rest)]) {
+				line.unknown = true
+			}
+			data = rest
+		default:
+			if r >= ' ' {
+				line.text = append(line.text, r)
+			} else {
+				line.unknown = true
+			}
+		}
+	}
+	return line, submitted
+}
+
+// passiveEscape reports whether an escape sequence, without its ESC, is
+// one the terminal sends without the line being edited: bracketed paste
+// markers and focus reports
+func passiveEscape(seq []byte) bool {
+	switch string(seq) {
+	case "[200~", "[201~", "[I", "[O":
+		return true
+	}
+	return false
+}
+
+// skipEscape skips the rest of an escape sequence whose ESC has been read
+func skipEscape(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	switch data[0] {
+	case '[':
+		// CSI: parameters and intermediates up to a final byte
+		for i := 1; i < len(data); i++ {
+			if data[i] >= 0x40 && data[i] <= 0x7e {
+				return data[i+1:]
+			}
+		}
+		return nil
+	case 'O':
+		// SS3, as sent by application cursor keys
+		if len(data) > 1 {
+			return data[2:]
+		}
+		return nil
+	default:
+		// Alt-modified key
+		return data[1:]
+	}
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import "testing"
+
+func TestEditLineFollowsTyping(t *testing.T) {
+	line, submitted := editLine(typedLine{}, []byte("ls -la\x7f\x7f\x17echo hi\r"))
+	if len(submitted) != 1 || string(submitted[0].text) != "ls echo hi" || submitted[0].unknown {
+		t.Fatalf("submitted %+v, want the known line \"ls echo hi\"", submitted)
+	}
+	if len(line.text) != 0 || line.unknown {
+		t.Errorf("line left %+v, want an empty known line", line)
+	}
+}
+
+func TestEditLineUnknownAfterEditingKeys(t *testing.T) {
+	for name, input := range map[string]string{
+		"history":    "\x1b[A\r",
+		"app cursor": "\x1bOA\r",
+		"completion": "rm -rf /tm\t\r",
+		"search":     "\x12rm\r",
+		"cursor":     "echo safe\x01rm -rf / #\r",
+	} {
+		_, submitted := editLine(typedLine{}, []byte(input))
+		if len(submitted) != 1 || !submitted[0].unknown {
+			t.Errorf("%s: submitted %+v, want an unknown line", name, submitted)
+		}
+	}
+}
+
+func TestEditLineCtrlCClearsUnknown(t *testing.T) {
+	line, _ := editLine(typedLine{}, []byte("\x1b[A"))
+	line, _ = editLine(line, []byte("\x15"))
+	if !line.unknown {
+		t.Error("^U made a line edited with arrows known")
+	}
+	line, submitted := editLine(line, []byte("\x03ls\r"))
+	if len(submitted) != 1 || submitted[0].unknown || string(submitted[0].text) != "ls" || line.unknown {
+		t.Errorf("after ^C submitted %+v, left %+v; want the known line ls", submitted, line)
+	}
+}
+
+func TestEditLineBracketedPaste(t *testing.T) {
+	_, submitted := editLine(typedLine{}, []byte("\x1b[200~uptime\x1b[201~\r"))
+	if len(submitted) != 1 || submitted[0].unknown || string(submitted[0].text) != "uptime" {
+		t.Errorf("submitted %+v, want the known line uptime", submitted)
+	}
+}
@@ -0,0 +1,243 @@
+package terminal
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCommandRecords bounds the command history kept per session
+const maxCommandRecords = 1000
+
+// CommandRecord is one shell command delimited by OSC 133 (FinalTerm) marks.
+// Offsets count bytes of session output; lines are absolute scrollback line numbers.
+type CommandRecord struct {
+	ID      int    `json:"id"`
+	Command string `json:"command"`
+	// PromptOffset is where the prompt was drawn (133;A)
+	PromptOffset int64 `json:"prompt_offset"`
+	// StartOffset is where the command's output begins (133;C)
+	StartOffset int64 `json:"start_offset"`
+	// EndOffset is where the command finished (133;D); 0 while running
+	EndOffset  int64      `json:"end_offset"`
+	PromptLine int64      `json:"prompt_line"`
+	StartLine  int64      `json:"start_line"`
+	EndLine    int64      `json:"end_line"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	// ExitCode is nil while running or when the shell did not report one
+	ExitCode *int `json:"exit_code,omitempty"`
+	Running  bool `json:"running"`
+}
+
+// commandPhase is where the tracker is within the prompt/command cycle
+type commandPhase int
+
+const (
+	phaseIdle    commandPhase = iota
+	phasePrompt               // prompt is being drawn (after A)
+	phaseInput                // user is typing the command (after B)
+	phaseRunning              // command is executing (after C)
+)
+
+// CommandTracker follows OSC 133 marks in session output and keeps a
+// bounded list of the commands run in the session
+type CommandTracker struct {
+	mu     sync.RWMutex
+	parser *vtParser
+
+	offset int64 // bytes parsed so far
+	line   int64 // newlines seen so far
+	// skipping ignores marks in output that did not come from the shell
+	skipping bool
+
+	phase      commandPhase
+	prompt     int64 // offset of the last prompt start
+	promptLine int64
+	input      []rune // echoed command text between B and C
+	current    *CommandRecord
+
+	records  []CommandRecord
+	nextID   int
+	finished []CommandRecord // completed during the current Write
+}
+
+// NewCommandTracker creates an empty tracker
+func NewCommandTracker() *CommandTracker {
+	t := &CommandTracker{}
+	t.parser = newVTParser(t)
+	return t
+}
+
+// Write parses a chunk of output and returns the commands that finished in it
+func (t *CommandTracker) Write(p []byte) []CommandRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, c := range p {
+		t.parser.advance(c)
+		t.offset++
+	}
+
+	finished := t.finished
+	t.finished = nil
+	return finished
+}
+
+// Skip counts output that did not come from the shell, such as restored
+// scrollback, so that offsets and lines stay in step with the session's
+// output. Marks in it belong to an earlier shell and are ignored.
+func (t *CommandTracker) Skip(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.skipping = true
+	for _, c := range p {
+		t.parser.advance(c)
+		t.offset++
+	}
+	t.skipping = false
+}
+
+// Commands returns the recorded commands, oldest first, including one still running
+func (t *CommandTracker) Commands() []CommandRecord {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]CommandRecord, 0, len(t.records)+1)
+	out = append(out, t.records...)
+	if t.current != nil {
+		running := *t.current
+		running.DurationMs = time.Since(running.StartedAt).Milliseconds()
+		out = append(out, running)
+	}
+	return out
+}
+
+// Last returns the most recently finished command
+func (t *CommandTracker) Last() (CommandRecord, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.records) == 0 {
+		return CommandRecord{}, false
+	}
+	return t.records[len(t.records)-1], true
+}
+
+// print implements vtHandler, collecting the echoed command line
+func (t *CommandTracker) print(r rune) {
+	if t.phase == phaseInput {
+		t.input = append(t.input, r)
+	}
+}
+
+// execute implements vtHandler
+func (t *CommandTracker) execute(c byte) {
+	switch c {
+	case '\n':
+		t.line++
+	case '\b':
+		if t.phase == phaseInput && len(t.input) > 0 {
+			t.input = t.input[:len(t.input)-1]
+		}
+	}
+}
+
+// csiDispatch implements vtHandler; control sequences carry no command marks
+func (t *CommandTracker) csiDispatch(params []int, private byte, intermediates []byte, final byte) {
+}
+
+// escDispatch implements vtHandler
+func (t *CommandTracker) escDispatch(intermediates []byte, final byte) {
+}
+
+// oscDispatch implements vtHandler for OSC 133 marks. The parser reports a
+// mark on its final byte, which is at t.offset.
+func (t *CommandTracker) oscDispatch(data []byte) {
+	fields := strings.Split(string(data), ";")
+	if t.skipping || len(fields) < 2 || fields[0] != "133" {
+		return
+	}
+	offset := t.offset + 1
+
+	switch fields[1] {
+	case "A":
+		// A prompt without a preceding D means the command's status was lost
+		t.finish(offset, nil)
+		t.phase = phasePrompt
+		t.prompt, t.promptLine = offset, t.line
+	case "B":
+		t.phase = phaseInput
+		t.input = t.input[:0]
+	case "C":
+		t.finish(offset, nil)
+		command := strings.TrimSpace(string(t.input))
+		if cmdline, ok := markParam(fields[2:], "cmdline_url"); ok {
+			if decoded, err := url.PathUnescape(cmdline); err == nil {
+				command = decoded
+			}
+		} else if _, cmdline, ok := strings.Cut(string(data), ";cmdline="); ok {
+			// An unencoded command line runs to the end of the mark
+			command = cmdline
+		}
+		t.nextID++
+		t.current = &CommandRecord{
+			ID:           t.nextID,
+			Command:      command,
+			PromptOffset: t.prompt,
+			StartOffset:  offset,
+			PromptLine:   t.promptLine,
+			StartLine:    t.line,
+			StartedAt:    time.Now(),
+			Running:      true,
+		}
+		t.phase = phaseRunning
+		t.input = t.input[:0]
+	case "D":
+		var code *int
+		if len(fields) > 2 {
+			if n, err := strconv.Atoi(fields[2]); err == nil {
+				code = &n
+			}
+		}
+		t.finish(offset, code)
+		t.phase = phaseIdle
+	}
+}
+
+// finish completes the running command, if any
+func (t *CommandTracker) finish(offset int64, code *int) {
+	if t.current == nil {
+		return
+	}
+	rec := *t.current
+	t.current = nil
+
+	now := time.Now()
+	rec.EndOffset = offset
+	rec.EndLine = t.line
+	rec.FinishedAt = &now
+	rec.DurationMs = now.Sub(rec.StartedAt).Milliseconds()
+	rec.ExitCode = code
+	rec.Running = false
+
+	if len(t.records) >= maxCommandRecords {
+		t.records = append(t.records[:0], t.records[1:]...)
+	}
+	t.records = append(t.records, rec)
+	t.finished = append(t.finished, rec)
+}
+
+// markParam finds a key=value parameter of an OSC 133 mark
+func markParam(params []string, key string) (string, bool) {
+	for _, param := range params {
+		if value, ok := strings.CutPrefix(param, key+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
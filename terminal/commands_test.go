@@ -0,0 +1,26 @@
+package terminal
+
+import "testing"
+
+func TestCommandTrackerSkipsPrelude(t *testing.T) {
+	prelude := []byte("\x1b]133;A\x07$ \x1b]133;B\x07old\x1b]133;C\x07\r\nold output\r\n\x1b]133;D;1\x07[restored]\r\n")
+	live := []byte("\x1b]133;A\x07$ \x1b]133;B\x07ls\x1b]133;C\x07\r\nfile\r\n\x1b]133;D;0\x07")
+
+	tracker := NewCommandTracker()
+	tracker.Skip(prelude)
+	finished := tracker.Write(live)
+
+	if len(finished) != 1 || len(tracker.Commands()) != 1 {
+		t.Fatalf("finished %d, recorded %d commands; want only the live one", len(finished), len(tracker.Commands()))
+	}
+	rec := finished[0]
+	if rec.Command != "ls" {
+		t.Errorf("command = %q, want ls", rec.Command)
+	}
+	if want := int64(len(prelude)) + 8; rec.PromptOffset != want {
+		t.Errorf("prompt offset = %d, want %d", rec.PromptOffset, want)
+	}
+	if rec.PromptLine != 3 || rec.StartLine != 3 || rec.EndLine != 5 {
+		t.Errorf("lines = %d, %d, %d; want 3, 3, 5", rec.PromptLine, rec.StartLine, rec.EndLine)
+	}
+}
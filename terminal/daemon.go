@@ -0,0 +1,484 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DaemonFlag is the command-line flag that runs the binary as the session daemon
+	DaemonFlag = "--session-daemon"
+	// daemonIdleTimeout is how long the daemon lingers with no sessions and no clients
+	daemonIdleTimeout = time.Minute
+	// daemonClientBuffer is how many output chunks may queue for a slow
+	// client before the shell's output is held up
+	daemonClientBuffer = 1024
+	// maxDaemonFrame bounds a single frame on a daemon connection
+	maxDaemonFrame = 1 << 20
+)
+
+// Frame types on an attached connection. Output and exit flow from the
+// daemon to the GUI; input and resize flow the other way.
+const (
+	frameOutput byte = 'o'
+	frameExit   byte = 'x'
+	frameInput  byte = 'i'
+	frameResize byte = 'r'
+)
+
+// DaemonSessionInfo describes a session owned by the session daemon
+type DaemonSessionInfo struct {
+	ID        string    `json:"id"`
+	Shell     string    `json:"shell"`
+	Profile   string    `json:"profile,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Attached  bool      `json:"attached"`
+}
+
+// daemonRequest is the first line a client sends on a connection
+type daemonRequest struct {
+	Op      string        `json:"op"` // create, list, attach, kill
+	ID      string        `json:"id,omitempty"`
+	Options LaunchOptions `json:"options,omitempty"`
+}
+
+// daemonResponse answers a request. After a successful attach the
+// connection switches to frames.
+type daemonResponse struct {
+	Error    string              `json:"error,omitempty"`
+	ID       string              `json:"id,omitempty"`
+	Shell    string              `json:"shell,omitempty"`
+	Sessions []DaemonSessionInfo `json:"sessions,omitempty"`
+}
+
+// DaemonSocketPath returns where the session daemon listens. It lives in
+// a private per-user directory, preferring $XDG_RUNTIME_DIR; the daemon
+// and its clients refuse the directory unless only this user can use it.
+func DaemonSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	user := fmt.Sprint(os.Getuid())
+	if os.Getuid() < 0 {
+		// Windows has no uid
+		user = localUser()
+	}
+	return filepath.Join(dir, "ai-terminal-"+user, "sessions.sock")
+}
+
+// writeFrame writes a type byte, a 4-byte big-endian length and the payload
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	header := make([]byte, 5, 5+len(payload))
+	header[0] = typ
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	_, err := w.Write(append(header, payload...))
+	return err
+}
+
+// readFrame reads one frame written by writeFrame
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxDaemonFrame {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// resizePayload encodes a terminal size for a resize frame
+func resizePayload(rows, cols int) []byte {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload, uint16(rows))
+	binary.BigEndian.PutUint16(payload[2:], uint16(cols))
+	return payload
+}
+
+// sessionDaemon owns shells on behalf of GUI clients, so they outlive the GUI
+type sessionDaemon struct {
+	mu         sync.Mutex
+	sessions   map[string]*daemonSession
+	nextID     int
+	conns      int
+	lastActive time.Time
+}
+
+// daemonSession is a shell owned by the daemon and the clients attached to it
+type daemonSession struct {
+	id         string
+	profile    string
+	backend    Backend
+	createdAt  time.Time
+	scrollback *Scrollback
+
+	mu      sync.Mutex
+	clients map[*daemonAttachment]bool
+}
+
+// daemonFrame is a frame queued for an attached client
+type daemonFrame struct {
+	typ     byte
+	payload []byte
+}
+
+// daemonAttachment is the queue of frames for one attached client
+type daemonAttachment struct {
+	out chan daemonFrame
+	// gone is closed once the client detaches
+	gone     chan struct{}
+	goneOnce sync.Once
+}
+
+// send queues a frame, waiting for room unless the client has gone
+func (a *daemonAttachment) send(frame daemonFrame) {
+	select {
+	case a.out <- frame:
+	case <-a.gone:
+	}
+}
+
+// RunSessionDaemon serves sessions on a Unix socket until it has been idle,
+// with no sessions and no clients, for a while. It returns an error if
+// another daemon is already listening.
+func RunSessionDaemon(socketPath string) error {
+	if err := ensurePrivateDir(filepath.Dir(socketPath)); err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return errors.New("session daemon is already running")
+	}
+	// Nothing answered, so any socket file left behind is stale
+	os.Remove(socketPath)
+
+	ln, err := listenPrivate(socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer ln.Close()
+
+	d := &sessionDaemon{
+		sessions:   make(map[string]*daemonSession),
+		lastActive: time.Now(),
+	}
+	go d.exitWhenIdle(ln)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go d.serve(conn)
+	}
+}
+
+// exitWhenIdle closes the listener once the daemon has nothing to do
+func (d *sessionDaemon) exitWhenIdle(ln net.Listener) {
+	ticker := time.NewTicker(daemonIdleTimeout / 4)
+	defer ticker.Stop()
+	for range ticker.C {
+		d.mu.Lock()
+		idle := len(d.sessions) == 0 && d.conns == 0 && time.Since(d.lastActive) > daemonIdleTimeout
+		d.mu.Unlock()
+		if idle {
+			ln.Close()
+			return
+		}
+	}
+}
+
+// serve handles one client connection
+func (d *sessionDaemon) serve(conn net.Conn) {
+	d.mu.Lock()
+	d.conns++
+	d.mu.Unlock()
+	defer func() {
+		conn.Close()
+		d.mu.Lock()
+		d.conns--
+		d.lastActive = time.Now()
+		d.mu.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req daemonRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		writeResponse(conn, daemonResponse{Error: "invalid request"})
+		return
+	}
+
+	switch req.Op {
+	case "create":
+		id, err := d.create(req.Options)
+		if err != nil {
+			writeResponse(conn, daemonResponse{Error: err.Error()})
+			return
+		}
+		writeResponse(conn, daemonResponse{ID: id})
+	case "list":
+		writeResponse(conn, daemonResponse{Sessions: d.list()})
+	case "kill":
+		if err := d.kill(req.ID); err != nil {
+			writeResponse(conn, daemonResponse{Error: err.Error()})
+			return
+		}
+		writeResponse(conn, daemonResponse{ID: req.ID})
+	case "attach":
+		d.attach(conn, reader, req.ID)
+	default:
+		writeResponse(conn, daemonResponse{Error: "unknown operation: " + req.Op})
+	}
+}
+
+// writeResponse sends a response as one JSON line
+func writeResponse(w io.Writer, resp daemonResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// create starts a shell owned by the daemon
+func (d *sessionDaemon) create(opts LaunchOptions) (string, error) {
+	backend, err := NewPTYSessionWithOptions(opts)
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	d.nextID++
+	s := &daemonSession{
+		id:         fmt.Sprintf("daemon-%d", d.nextID),
+		profile:    opts.Profile,
+		backend:    backend,
+		createdAt:  time.Now(),
+		scrollback: NewScrollback(),
+		clients:    make(map[*daemonAttachment]bool),
+	}
+	d.sessions[s.id] = s
+	d.mu.Unlock()
+
+	go d.run(s)
+	return s.id, nil
+}
+
+// run reads a shell's output into its scrollback and out to attached
+// clients, and forgets the session once the shell exits
+func (d *sessionDaemon) run(s *daemonSession) {
+	// Background jobs can keep the PTY open after the shell exits, so stop
+	// reading once the shell is gone and its last output has had time to drain
+	drained := make(chan struct{})
+	go func() {
+		s.backend.Wait()
+		select {
+		case <-drained:
+		case <-time.After(exitDrainTimeout):
+			s.backend.Close()
+		}
+	}()
+
+	buf := make([]byte, readBufferSize)
+	for {
+		n, err := s.backend.Read(buf)
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			s.mu.Lock()
+			s.scrollback.Write(chunk)
+			clients := s.attachedLocked()
+			s.mu.Unlock()
+			// A client that falls behind holds up the shell's output, as the
+			// GUI's flow control does, rather than being dropped
+			for _, a := range clients {
+				a.send(daemonFrame{frameOutput, chunk})
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	close(drained)
+
+	// Closing hangs up any jobs the shell left behind
+	s.backend.Close()
+	status, _ := s.backend.Wait()
+	exit, _ := json.Marshal(status)
+
+	d.mu.Lock()
+	delete(d.sessions, s.id)
+	d.lastActive = time.Now()
+	d.mu.Unlock()
+
+	s.mu.Lock()
+	clients := s.attachedLocked()
+	s.clients = nil
+	s.mu.Unlock()
+	for _, a := range clients {
+		a.send(daemonFrame{frameExit, exit})
+	}
+}
+
+// attachedLocked returns the attached clients. The caller holds s.mu.
+func (s *daemonSession) attachedLocked() []*daemonAttachment {
+	clients := make([]*daemonAttachment, 0, len(s.clients))
+	for a := range s.clients {
+		clients = append(clients, a)
+	}
+	return clients
+}
+
+// list describes the daemon's sessions, oldest first
+func (d *sessionDaemon) list() []DaemonSessionInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	infos := make([]DaemonSessionInfo, 0, len(d.sessions))
+	for _, s := range d.sessions {
+		s.mu.Lock()
+		attached := len(s.clients) > 0
+		s.mu.Unlock()
+		infos = append(infos, DaemonSessionInfo{
+			ID:        s.id,
+			Shell:     s.backend.GetShell(),
+			Profile:   s.profile,
+			CreatedAt: s.createdAt,
+			Attached:  attached,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos
+}
+
+// get looks up a daemon session
+func (d *sessionDaemon) get(id string) (*daemonSession, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	return s, nil
+}
+
+// kill ends a shell and everything it started
+func (d *sessionDaemon) kill(id string) error {
+	s, err := d.get(id)
+	if err != nil {
+		return err
+	}
+	return s.backend.Close()
+}
+
+// attach streams a session to a client, starting with its scrollback
+func (d *sessionDaemon) attach(conn net.Conn, reader *bufio.Reader, id string) {
+	s, err := d.get(id)
+	if err != nil {
+		writeResponse(conn, daemonResponse{Error: err.Error()})
+		return
+	}
+
+	client := &daemonAttachment{
+		out:  make(chan daemonFrame, daemonClientBuffer),
+		gone: make(chan struct{}),
+	}
+	s.mu.Lock()
+	if s.clients == nil {
+		s.mu.Unlock()
+		writeResponse(conn, daemonResponse{Error: fmt.Sprintf("session %s has exited", id)})
+		return
+	}
+	// Taking the replay under the lock keeps it in order with live output
+	replay := s.scrollback.Raw()
+	s.clients[client] = true
+	s.mu.Unlock()
+
+	if err := writeResponse(conn, daemonResponse{ID: id, Shell: s.backend.GetShell()}); err != nil {
+		s.detach(client)
+		return
+	}
+
+	// Input from the client
+	go func() {
+		defer conn.Close()
+		for {
+			typ, payload, err := readFrame(reader)
+			if err != nil {
+				s.detach(client)
+				return
+			}
+			switch typ {
+			case frameInput:
+				s.backend.Write(payload)
+			case frameResize:
+				if len(payload) == 4 {
+					rows := int(binary.BigEndian.Uint16(payload))
+					cols := int(binary.BigEndian.Uint16(payload[2:]))
+					s.backend.Resize(rows, cols)
+				}
+			}
+		}
+	}()
+
+	for len(replay) > 0 {
+		n := len(replay)
+		if n > maxDaemonFrame {
+			n = maxDaemonFrame
+		}
+		if writeFrame(conn, frameOutput, replay[:n]) != nil {
+			s.detach(client)
+			return
+		}
+		replay = replay[n:]
+	}
+
+	for {
+		select {
+		case frame := <-client.out:
+			if writeFrame(conn, frame.typ, frame.payload) != nil {
+				s.detach(client)
+				return
+			}
+			if frame.typ == frameExit {
+				conn.Close()
+				return
+			}
+		case <-client.gone:
+			return
+		}
+	}
+}
+
+// detach stops sending output to a client
+func (s *daemonSession) detach(client *daemonAttachment) {
+	s.mu.Lock()
+	delete(s.clients, client)
+	s.mu.Unlock()
+	client.goneOnce.Do(func() { close(client.gone) })
+}
@@ -0,0 +1,270 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// daemonStartTimeout is how long to wait for a spawned daemon to listen
+const daemonStartTimeout = 5 * time.Second
+
+// DaemonClient talks to the session daemon
+type DaemonClient struct {
+	socketPath string
+}
+
+// ConnectDaemon connects to the session daemon at socketPath, starting it
+// from this executable if it is not running
+func ConnectDaemon(socketPath string) (*DaemonClient, error) {
+	// A daemon listening in a directory someone else controls may not be ours
+	if err := ensurePrivateDir(filepath.Dir(socketPath)); err != nil {
+		return nil, err
+	}
+
+	c := &DaemonClient{socketPath: socketPath}
+	if _, err := c.List(); err == nil {
+		return c, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find executable: %w", err)
+	}
+	cmd := exec.Command(exe, DaemonFlag)
+	detachDaemon(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start session daemon: %w", err)
+	}
+	// Reap the daemon if it exits while the app is still running
+	go cmd.Wait()
+
+	deadline := time.Now().Add(daemonStartTimeout)
+	for {
+		_, err := c.List()
+		if err == nil {
+			return c, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("session daemon did not start: %w", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// request sends one request and reads the response, leaving the
+// connection open for callers that go on to attach
+func (c *DaemonClient) request(req daemonRequest) (net.Conn, *bufio.Reader, daemonResponse, error) {
+	var resp daemonResponse
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return nil, nil, resp, fmt.Errorf("failed to connect to session daemon: %w", err)
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, resp, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		conn.Close()
+		return nil, nil, resp, fmt.Errorf("failed to send request: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return nil, nil, resp, fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		conn.Close()
+		return nil, nil, resp, fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.Error != "" {
+		conn.Close()
+		return nil, nil, resp, errors.New(resp.Error)
+	}
+	return conn, reader, resp, nil
+}
+
+// call sends a request that needs no connection afterwards
+func (c *DaemonClient) call(req daemonRequest) (daemonResponse, error) {
+	conn, _, resp, err := c.request(req)
+	if err != nil {
+		return resp, err
+	}
+	conn.Close()
+	return resp, nil
+}
+
+// List returns the sessions the daemon owns
+func (c *DaemonClient) List() ([]DaemonSessionInfo, error) {
+	resp, err := c.call(daemonRequest{Op: "list"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// Start launches a shell in the daemon and attaches to it
+func (c *DaemonClient) Start(opts LaunchOptions) (*DaemonSession, error) {
+	resp, err := c.call(daemonRequest{Op: "create", Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	return c.Attach(resp.ID)
+}
+
+// Attach connects to a daemon session. Its scrollback is replayed before
+// live output.
+func (c *DaemonClient) Attach(id string) (*DaemonSession, error) {
+	conn, reader, resp, err := c.request(daemonRequest{Op: "attach", ID: id})
+	if err != nil {
+		return nil, err
+	}
+	return &DaemonSession{
+		client: c,
+		id:     id,
+		shell:  resp.Shell,
+		conn:   conn,
+		reader: reader,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// Kill ends a daemon session's shell
+func (c *DaemonClient) Kill(id string) error {
+	_, err := c.call(daemonRequest{Op: "kill", ID: id})
+	return err
+}
+
+// DaemonSession is a Backend for a shell owned by the session daemon.
+// Closing it only detaches; the shell keeps running until killed.
+type DaemonSession struct {
+	client *DaemonClient
+	id     string
+	shell  string
+	conn   net.Conn
+	reader *bufio.Reader
+
+	// pending is output from a frame larger than the last Read
+	pending []byte
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	status   ExitStatus
+	waitErr  error
+	detached bool
+	once     sync.Once
+	done     chan struct{}
+}
+
+// DaemonID returns the session's ID within the daemon
+func (s *DaemonSession) DaemonID() string {
+	return s.id
+}
+
+// Read returns output from the daemon, or io.EOF once the shell has
+// exited, the session is detached or the daemon goes away
+func (s *DaemonSession) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		typ, payload, err := readFrame(s.reader)
+		if err != nil {
+			s.mu.Lock()
+			if !s.detached {
+				// The shell's fate is unknown, so this is not reported as
+				// a clean exit
+				s.status = ExitStatus{Code: -1}
+				s.waitErr = fmt.Errorf("lost connection to session daemon: %w", err)
+			}
+			s.mu.Unlock()
+			s.end()
+			return 0, io.EOF
+		}
+		switch typ {
+		case frameOutput:
+			s.pending = payload
+		case frameExit:
+			var status ExitStatus
+			json.Unmarshal(payload, &status)
+			s.mu.Lock()
+			s.status = status
+			s.mu.Unlock()
+			s.end()
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// Write sends input to the shell
+func (s *DaemonSession) Write(p []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	for start := 0; start < len(p); start += maxDaemonFrame {
+		end := start + maxDaemonFrame
+		if end > len(p) {
+			end = len(p)
+		}
+		if err := writeFrame(s.conn, frameInput, p[start:end]); err != nil {
+			return start, err
+		}
+	}
+	return len(p), nil
+}
+
+// Resize sets the shell's terminal size
+func (s *DaemonSession) Resize(rows, cols int) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return writeFrame(s.conn, frameResize, resizePayload(rows, cols))
+}
+
+// Close detaches from the daemon, leaving the shell running
+func (s *DaemonSession) Close() error {
+	s.mu.Lock()
+	s.detached = true
+	s.mu.Unlock()
+	err := s.conn.Close()
+	s.end()
+	return err
+}
+
+// Kill ends the shell inside the daemon
+func (s *DaemonSession) Kill() error {
+	return s.client.Kill(s.id)
+}
+
+// end marks the session as over
+func (s *DaemonSession) end() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Wait blocks until the shell exits or the session is detached. It
+// returns an error if the connection to the daemon was lost.
+func (s *DaemonSession) Wait() (ExitStatus, error) {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status, s.waitErr
+}
+
+// GetShell returns the shell the daemon is running
+func (s *DaemonSession) GetShell() string {
+	return s.shell
+}
+
+// Kind identifies DaemonSession as the daemon backend
+func (s *DaemonSession) Kind() string {
+	return "daemon"
+}
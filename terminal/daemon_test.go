@@ -0,0 +1,144 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDaemonSlowClientKeepsOutput(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "daemon", "sessions.sock")
+	go RunSessionDaemon(sock)
+
+	c := &DaemonClient{socketPath: sock}
+	deadline := time.Now().Add(daemonStartTimeout)
+	for {
+		if _, err := c.List(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session daemon did not start")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	const size = 20_000_000
+	s, err := c.Start(LaunchOptions{
+		Shell: "/bin/sh",
+		Args:  []string{"-c", "head -c 20000000 /dev/zero | tr '\\0' x; exit 7"},
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Close()
+
+	// Fall far enough behind to fill the daemon's queue for this client
+	time.Sleep(2 * time.Second)
+
+	n, err := io.Copy(io.Discard, s)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	status, err := s.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if n < size || status.Code != 7 {
+		t.Errorf("read %d bytes with exit code %d, want at least %d bytes and code 7", n, status.Code, size)
+	}
+}
+
+func TestDaemonSessionEndsDespiteBackgroundJobs(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "daemon", "sessions.sock")
+	go RunSessionDaemon(sock)
+
+	c := &DaemonClient{socketPath: sock}
+	deadline := time.Now().Add(daemonStartTimeout)
+	for {
+		if _, err := c.List(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session daemon did not start")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// The job holds the PTY open long after the shell has gone
+	s, err := c.Start(LaunchOptions{
+		Shell: "/bin/sh",
+		Args:  []string{"-c", "(trap '' HUP; exec sleep 30) & echo bye; exit 4"},
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Close()
+
+	done := make(chan []byte, 1)
+	go func() {
+		out, _ := io.ReadAll(s)
+		done <- out
+	}()
+	select {
+	case out := <-done:
+		if !strings.Contains(string(out), "bye") {
+			t.Errorf("output = %q, want the shell's last words", out)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("session did not end after its shell exited")
+	}
+	if status, err := s.Wait(); err != nil || status.Code != 4 {
+		t.Errorf("Wait = %+v, %v; want code 4", status, err)
+	}
+}
+
+func TestEnsurePrivateDir(t *testing.T) {
+	base := t.TempDir()
+
+	fresh := filepath.Join(base, "fresh")
+	if err := ensurePrivateDir(fresh); err != nil {
+		t.Fatalf("new directory: %v", err)
+	}
+	if err := ensurePrivateDir(fresh); err != nil {
+		t.Fatalf("existing private directory: %v", err)
+	}
+
+	open := filepath.Join(base, "open")
+	if err := os.Mkdir(open, 0700); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(open, 0755)
+	if err := ensurePrivateDir(open); err == nil {
+		t.Error("accepted a directory others can read")
+	}
+
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(fresh, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensurePrivateDir(link); err == nil {
+		t.Error("accepted a symlink")
+	}
+}
+
+func TestDaemonSocketIsPrivate(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "sessions.sock")
+	ln, err := listenPrivate(sock)
+	if err != nil {
+		t.Fatalf("listenPrivate: %v", err)
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %#o, want 0600", perm)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachDaemon starts the daemon in its own session so it survives the app
+// and its terminal
+func detachDaemon(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// ensurePrivateDir creates the socket directory, or checks that an
+// existing one is ours and closed to everyone else. Another local user
+// could otherwise create it first and intercept the socket.
+func ensurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket directory: %w", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || !ok {
+		return fmt.Errorf("refusing to use %s: not a directory", dir)
+	}
+	if int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("refusing to use %s: owned by uid %d", dir, stat.Uid)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		return fmt.Errorf("refusing to use %s: mode %#o is not 0700", dir, perm)
+	}
+	return nil
+}
+
+// listenPrivate listens on a Unix socket only this user can connect to.
+// The umask is in effect as the socket file is created, so it is never
+// open to others.
+func listenPrivate(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
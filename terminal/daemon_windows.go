@@ -0,0 +1,35 @@
+//go:build windows
+
+package terminal
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess is the DETACHED_PROCESS creation flag, which the syscall
+// package does not define
+const detachedProcess = 0x00000008
+
+// detachDaemon starts the daemon without a console in its own process
+// group so it survives the app
+func detachDaemon(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// ensurePrivateDir creates the socket directory. It lives in the user's
+// own temporary directory, which other users cannot reach.
+func ensurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	return nil
+}
+
+// listenPrivate listens on a Unix socket in the user's directory
+func listenPrivate(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
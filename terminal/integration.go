@@ -0,0 +1,119 @@
+package terminal
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// integrationScripts holds the shell snippets that emit OSC 133 prompt and
+// command marks and OSC 7 working-directory reports
+//
+//go:embed integration
+var integrationScripts embed.FS
+
+// integrationFiles maps embedded scripts to their installed names. zsh only
+// reads dotfiles from ZDOTDIR, which embed cannot include directly.
+var integrationFiles = map[string]string{
+	"integration/bash/rc.bash":          "bash/rc.bash",
+	"integration/zsh/zshenv":            "zsh/.zshenv",
+	"integration/zsh/zprofile":          "zsh/.zprofile",
+	"integration/zsh/zshrc":             "zsh/.zshrc",
+	"integration/fish/integration.fish": "fish/integration.fish",
+}
+
+var (
+	integrationOnce sync.Once
+	integrationDir  string
+	integrationErr  error
+)
+
+// installIntegration writes the integration scripts to the user's cache
+// directory once per process and returns where they are
+func installIntegration() (string, error) {
+	integrationOnce.Do(func() {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			integrationErr = fmt.Errorf("failed to find cache directory: %w", err)
+			return
+		}
+		dir := filepath.Join(cache, "ai-terminal", "shell-integration")
+
+		for src, dst := range integrationFiles {
+			data, err := fs.ReadFile(integrationScripts, src)
+			if err != nil {
+				integrationErr = fmt.Errorf("failed to read %s: %w", src, err)
+				return
+			}
+			path := filepath.Join(dir, dst)
+			if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				integrationErr = fmt.Errorf("failed to create shell integration directory: %w", err)
+				return
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				integrationErr = fmt.Errorf("failed to write shell integration: %w", err)
+				return
+			}
+		}
+		integrationDir = dir
+	})
+	return integrationDir, integrationErr
+}
+
+// integrationLaunch returns the arguments and extra environment that start
+// shellPath with integration loaded, without touching the user's dotfiles.
+// ok is false for shells without integration support.
+func integrationLaunch(shellPath string, login bool) (args, env []string, ok bool, err error) {
+	name := strings.TrimSuffix(filepath.Base(shellPath), ".exe")
+	switch name {
+	case "bash", "zsh", "fish":
+	default:
+		return nil, nil, false, nil
+	}
+
+	dir, err := installIntegration()
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	switch name {
+	case "bash":
+		// bash ignores --rcfile in login shells, so the rcfile sources the
+		// login files itself when asked to
+		args = []string{"--rcfile", filepath.Join(dir, "bash", "rc.bash")}
+		if login {
+			env = append(env, "AI_TERMINAL_BASH_LOGIN=1")
+		}
+
+	case "zsh":
+		if login {
+			args = append(args, "-l")
+		}
+		if zdotdir := os.Getenv("ZDOTDIR"); zdotdir != "" {
+			env = append(env, "AI_TERMINAL_USER_ZDOTDIR="+zdotdir)
+		}
+		env = append(env, "ZDOTDIR="+filepath.Join(dir, "zsh"))
+
+	case "fish":
+		if login {
+			args = append(args, "-l")
+		}
+		script := filepath.Join(dir, "fish", "integration.fish")
+		args = append(args, "--init-command", "source "+fishQuote(script))
+	}
+
+	return args, env, true, nil
+}
+
+// fishQuote quotes s as a single-quoted fish string
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
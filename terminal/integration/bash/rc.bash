@@ -0,0 +1,87 @@
+# AI Terminal Pro shell integration for bash.
+# Loaded with --rcfile in place of ~/.bashrc, so it sources the user's own
+# startup files first and then reports prompts, commands and the working
+# directory with OSC 133 and OSC 7 sequences.
+
+if [ -n "$AI_TERMINAL_BASH_LOGIN" ]; then
+    unset AI_TERMINAL_BASH_LOGIN
+    [ -r /etc/profile ] && . /etc/profile
+    if [ -r ~/.bash_profile ]; then
+        . ~/.bash_profile
+    elif [ -r ~/.bash_login ]; then
+        . ~/.bash_login
+    elif [ -r ~/.profile ]; then
+        . ~/.profile
+    fi
+else
+    [ -r ~/.bashrc ] && . ~/.bashrc
+fi
+
+if [ -z "$__aiterm_installed" ]; then
+    __aiterm_installed=1
+    __aiterm_state=
+
+    __aiterm_urlencode() {
+        local LC_ALL=C s="$1" out= c i
+        for ((i = 0; i < ${#s}; i++)); do
+            c=${s:i:1}
+            case "$c" in
+                [a-zA-Z0-9/._~-]) out+=$c ;;
+                *) printf -v c '%%%02X' "'$c"; out+=$c ;;
+            esac
+        done
+        printf '%s' "$out"
+    }
+
+    # Runs first in PROMPT_COMMAND so $? is still the command's status
+    __aiterm_precmd() {
+        local status=$?
+        if [ "$__aiterm_state" = running ]; then
+            printf '\e]133;D;%s\a' "$status"
+        fi
+        __aiterm_state=
+        printf '\e]7;file://%s%s\a' "$HOSTNAME" "$(__aiterm_urlencode "$PWD")"
+        return $status
+    }
+
+    # Runs last in PROMPT_COMMAND, after any user hooks have set PS1
+    __aiterm_prompt() {
+        local status=$?
+        printf '\e]133;A\a'
+        case "$PS1" in
+            *'133;B'*) ;;
+            *) PS1="$PS1"'\[\e]133;B\a\]' ;;
+        esac
+        __aiterm_state=prompt
+        return $status
+    }
+
+    # The DEBUG trap fires before every simple command; only the first one
+    # after a prompt starts a new command line. An empty line goes straight
+    # to PROMPT_COMMAND and runs nothing.
+    __aiterm_preexec() {
+        [ "$__aiterm_state" = prompt ] || return
+        [ -n "$COMP_LINE" ] && return
+        if [ "$BASH_COMMAND" = __aiterm_precmd ]; then
+            __aiterm_state=
+            return
+        fi
+        __aiterm_state=running
+        local cmd
+        cmd=$(HISTTIMEFORMAT= builtin history 1)
+        cmd="${cmd#"${cmd%%[![:space:]]*}"}"
+        cmd="${cmd#*[[:space:]]}"
+        cmd="${cmd#"${cmd%%[![:space:]]*}"}"
+        printf '\e]133;C;cmdline_url=%s\a' "$(__aiterm_urlencode "$cmd")"
+    }
+
+    # bash 5.1 also accepts PROMPT_COMMAND as an array; keep its elements
+    if [[ "$(declare -p PROMPT_COMMAND 2>/dev/null)" == "declare -a"* ]]; then
+        PROMPT_COMMAND=(__aiterm_precmd "${PROMPT_COMMAND[@]}" __aiterm_prompt)
+    else
+        PROMPT_COMMAND="__aiterm_precmd${PROMPT_COMMAND:+; $PROMPT_COMMAND}; __aiterm_prompt"
+    fi
+    if [ -z "$(trap -p DEBUG)" ]; then
+        trap '__aiterm_preexec' DEBUG
+    fi
+fi
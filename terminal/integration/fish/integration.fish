@@ -0,0 +1,38 @@
+# AI Terminal Pro shell integration for fish.
+# Loaded with --init-command after the user's configuration; reports
+# prompts, commands and the working directory with OSC 133 and OSC 7 sequences.
+
+if not set -q __aiterm_installed
+    set -g __aiterm_installed 1
+    set -g __aiterm_state
+
+    function __aiterm_preexec --on-event fish_preexec
+        set -g __aiterm_state running
+        printf '\e]133;C;cmdline_url=%s\a' (string escape --style=url -- $argv[1])
+    end
+
+    function __aiterm_postexec --on-event fish_postexec
+        set -g __aiterm_status $status
+    end
+
+    function __aiterm_prompt --on-event fish_prompt
+        if test "$__aiterm_state" = running
+            printf '\e]133;D;%s\a' $__aiterm_status
+        end
+        set -g __aiterm_state prompt
+        printf '\e]7;file://%s%s\a' (prompt_hostname) (string escape --style=url -- $PWD)
+        printf '\e]133;A\a'
+    end
+
+    # Mark the end of the prompt, keeping $status intact for the user's prompt
+    function __aiterm_return
+        return $argv[1]
+    end
+    functions -c fish_prompt __aiterm_user_prompt
+    function fish_prompt
+        set -l last_status $status
+        __aiterm_return $last_status
+        __aiterm_user_prompt
+        printf '\e]133;B\a'
+    end
+end
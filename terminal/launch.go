@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LaunchOptions controls how a local shell is started
+type LaunchOptions struct {
+	// Profile names the shell profile these options came from, for display
+	Profile string
+	// Shell is the shell executable; empty uses the detected default
+	Shell string
+	// Args are passed to the shell after any arguments the app adds
+	Args []string
+	// Login starts the shell as a login shell
+	Login bool
+	// Dir is the starting directory; empty inherits the app's, and a
+	// leading ~ refers to the home directory
+	Dir string
+	// Env holds KEY=VALUE pairs added to the environment
+	Env []string
+	// UnsetEnv names variables removed from the inherited environment
+	UnsetEnv []string
+	// StartupCommands are typed into the shell once it has started
+	StartupCommands []string
+	// Integration loads prompt hooks that report commands and the working directory
+	Integration bool
+}
+
+// launchEnv builds the shell's environment from the app's own, with the
+// options' removals and additions applied
+func launchEnv(opts LaunchOptions) []string {
+	drop := make(map[string]bool, len(opts.UnsetEnv)+len(opts.Env))
+	for _, key := range opts.UnsetEnv {
+		drop[key] = true
+	}
+	for _, kv := range opts.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		drop[key] = true
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if !drop[key] {
+			env = append(env, kv)
+		}
+	}
+	return append(env, opts.Env...)
+}
+
+// launchDir resolves the starting directory, expanding a leading ~
+func launchDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid starting directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid starting directory: %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// startupInput returns the startup commands as typed input, one per line
+func startupInput(commands []string, newline string) []byte {
+	var b strings.Builder
+	for _, command := range commands {
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+		b.WriteString(command)
+		b.WriteString(newline)
+	}
+	return []byte(b.String())
+}
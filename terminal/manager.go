@@ -0,0 +1,385 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionManager creates and tracks terminal sessions by ID
+type SessionManager struct {
+	ctx   context.Context
+	hooks Hooks
+
+	mu          sync.RWMutex
+	sessions    map[string]*Session
+	nextID      int
+	integration bool
+	// tmux holds attached tmux control clients by session name
+	tmux map[string]*TmuxClient
+	// daemon, when set, owns new local shells so they outlive the app
+	daemon *DaemonClient
+	// groups holds broadcast groups by name
+	groups map[string]*broadcastGroup
+}
+
+// NewSessionManager creates an empty session manager. Cancelling ctx ends every session.
+func NewSessionManager(ctx context.Context, hooks Hooks) *SessionManager {
+	return &SessionManager{
+		ctx:      ctx,
+		hooks:    hooks,
+		sessions: make(map[string]*Session),
+		tmux:     make(map[string]*TmuxClient),
+		groups:   make(map[string]*broadcastGroup),
+	}
+}
+
+// Create starts a new session. An empty shellPath uses the detected default shell.
+func (m *SessionManager) Create(shellPath string) (*Session, error) {
+	opts := m.shellOptions(shellPath)
+	pty, err := m.launch(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.adoptLaunched(pty, opts, nil), nil
+}
+
+// CreateWithOptions starts a new session with fully specified launch
+// options, such as those of a shell profile
+func (m *SessionManager) CreateWithOptions(opts LaunchOptions) (*Session, error) {
+	backend, err := m.launch(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.adoptLaunched(backend, opts, nil), nil
+}
+
+// CreateSSH connects to a remote host and starts a session running its
+// login shell
+func (m *SessionManager) CreateSSH(cfg SSHConfig) (*Session, error) {
+	backend, err := NewSSHSession(m.ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	session := m.Adopt(backend)
+	session.screen.Resize(backend.Size())
+	return session, nil
+}
+
+// SetDaemon routes new local shells through the session daemon. Pass nil
+// to start them in-process again.
+func (m *SessionManager) SetDaemon(client *DaemonClient) {
+	m.mu.Lock()
+	m.daemon = client
+	m.mu.Unlock()
+}
+
+// AttachDaemonSessions adopts the daemon's sessions that no client is
+// attached to, such as those left running when the app last exited
+func (m *SessionManager) AttachDaemonSessions() ([]*Session, error) {
+	m.mu.RLock()
+	daemon := m.daemon
+	m.mu.RUnlock()
+	if daemon == nil {
+		return nil, fmt.Errorf("session daemon is not enabled")
+	}
+
+	infos, err := daemon.List()
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	for _, info := range infos {
+		if info.Attached {
+			continue
+		}
+		backend, err := daemon.Attach(info.ID)
+		if err != nil {
+			fmt.Printf("Failed to attach daemon session %s: %v\n", info.ID, err)
+			continue
+		}
+		sessions = append(sessions, m.adopt(backend, info.Profile))
+	}
+	return sessions, nil
+}
+
+// AttachTmux attaches to a tmux session in control mode, creating it if
+// needed. Each tmux pane, existing or new, becomes a session of its own.
+func (m *SessionManager) AttachTmux(target string) error {
+	m.mu.RLock()
+	_, attached := m.tmux[target]
+	m.mu.RUnlock()
+	if attached {
+		return fmt.Errorf("tmux session %s is already attached", target)
+	}
+
+	client, err := startTmuxClient(target, func(pane *TmuxPane) {
+		session := m.Adopt(pane)
+		if rows, cols := pane.Size(); rows > 0 && cols > 0 {
+			session.screen.Resize(rows, cols)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.tmux[client.Target()] = client
+	m.mu.Unlock()
+
+	go func() {
+		<-client.Done()
+		m.mu.Lock()
+		if m.tmux[client.Target()] == client {
+			delete(m.tmux, client.Target())
+		}
+		m.mu.Unlock()
+	}()
+	return nil
+}
+
+// DetachTmux detaches from a tmux session. Its panes end as sessions but
+// keep running inside tmux.
+func (m *SessionManager) DetachTmux(target string) error {
+	m.mu.Lock()
+	client, ok := m.tmux[target]
+	delete(m.tmux, target)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("tmux session %s is not attached", target)
+	}
+	client.Detach()
+	return nil
+}
+
+// Adopt starts a session around an already running backend
+func (m *SessionManager) Adopt(backend Backend) *Session {
+	return m.adopt(backend, "")
+}
+
+// adopt registers and starts a session launched from the named profile
+func (m *SessionManager) adopt(backend Backend, profile string) *Session {
+	return m.register(backend, profile, nil, nil)
+}
+
+// adoptLaunched adopts a local shell started from opts, whose output starts
+// with prelude. The options are kept for when the shell is restarted.
+func (m *SessionManager) adoptLaunched(backend Backend, opts LaunchOptions, prelude []byte) *Session {
+	return m.register(backend, opts.Profile, &opts, prelude)
+}
+
+// register starts a session under a new ID
+func (m *SessionManager) register(backend Backend, profile string, opts *LaunchOptions, prelude []byte) *Session {
+	m.mu.Lock()
+	m.nextID++
+	session := newSession(m.ctx, fmt.Sprintf("session-%d", m.nextID), backend, time.Now(), m.hooks)
+	session.profile = profile
+	session.prelude = prelude
+	session.launch = opts
+	m.sessions[session.id] = session
+	m.mu.Unlock()
+
+	go session.run()
+
+	return session
+}
+
+// Restore starts a new shell for a saved session in its last working
+// directory. The saved scrollback is shown above the new prompt.
+func (m *SessionManager) Restore(saved SavedSession, opts LaunchOptions) (*Session, error) {
+	if saved.Dir != "" {
+		if _, err := launchDir(saved.Dir); err == nil {
+			opts.Dir = saved.Dir
+		}
+	}
+
+	backend, err := m.launch(opts)
+	if err != nil {
+		return nil, err
+	}
+	return m.adoptLaunched(backend, opts, restorePrelude(saved)), nil
+}
+
+// SaveAll captures every session that can be restored, oldest first
+func (m *SessionManager) SaveAll() []SavedSession {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].createdAt.Before(sessions[j].createdAt)
+	})
+	var saved []SavedSession
+	for _, s := range sessions {
+		if state, ok := s.Save(); ok {
+			saved = append(saved, state)
+		}
+	}
+	return saved
+}
+
+// Replace ends the session with the given ID and starts a new shell under
+// the same ID. A shell started from a profile is started again with the
+// profile's options, switching only to shellPath if one is given.
+func (m *SessionManager) Replace(id, shellPath string) (*Session, error) {
+	old, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	// Restarting a shell also ends one that would outlive the session
+	if k, ok := old.backend.(killer); ok {
+		if err := k.Kill(); err != nil {
+			fmt.Printf("Failed to kill session %s: %v\n", id, err)
+		}
+	}
+	old.stop()
+
+	opts := m.shellOptions(shellPath)
+	if old.launch != nil {
+		opts = *old.launch
+		if shellPath != "" && shellPath != opts.Shell {
+			// Arguments are meant for the profile's own shell
+			opts.Shell, opts.Args = shellPath, nil
+		}
+	}
+	pty, err := m.launch(opts)
+	if err != nil {
+		m.mu.Lock()
+		if m.sessions[id] == old {
+			delete(m.sessions, id)
+		}
+		m.mu.Unlock()
+		return nil, err
+	}
+
+	// Keep the original creation time so the session keeps its place in List
+	session := newSession(m.ctx, id, pty, old.createdAt, m.hooks)
+	session.profile = old.profile
+	session.launch = &opts
+
+	m.mu.Lock()
+	m.sessions[id] = session
+	m.mu.Unlock()
+
+	go session.run()
+
+	return session, nil
+}
+
+// Get looks up a session by ID
+func (m *SessionManager) Get(id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	return session, nil
+}
+
+// List returns all sessions ordered by creation time
+func (m *SessionManager) List() []SessionInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]SessionInfo, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		infos = append(infos, s.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos
+}
+
+// Close terminates a session, removes it from the manager and reports any
+// processes that survived
+func (m *SessionManager) Close(id string) (ShutdownReport, error) {
+	m.mu.Lock()
+	session, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.leaveGroupsLocked(id)
+	m.mu.Unlock()
+
+	if !ok {
+		return ShutdownReport{}, fmt.Errorf("session %s not found", id)
+	}
+	// Closing a session on purpose also ends processes that would otherwise
+	// outlive it, unlike CloseAll when the app exits
+	if k, ok := session.backend.(killer); ok {
+		if err := k.Kill(); err != nil {
+			fmt.Printf("Failed to kill session %s: %v\n", id, err)
+		}
+	}
+	return session.stop(), nil
+}
+
+// CloseAll terminates every session in parallel, waits for their readers
+// to exit and returns a report per session. tmux and daemon sessions are
+// detached rather than killed, so they can be attached again on the next start.
+func (m *SessionManager) CloseAll() []ShutdownReport {
+	m.mu.Lock()
+	sessions := m.sessions
+	m.sessions = make(map[string]*Session)
+	clients := m.tmux
+	m.tmux = make(map[string]*TmuxClient)
+	m.mu.Unlock()
+
+	for _, client := range clients {
+		client.Detach()
+	}
+
+	var wg sync.WaitGroup
+	reports := make([]ShutdownReport, len(sessions))
+	i := 0
+	for _, s := range sessions {
+		wg.Add(1)
+		go func(i int, s *Session) {
+			defer wg.Done()
+			reports[i] = s.stop()
+		}(i, s)
+		i++
+	}
+	wg.Wait()
+	return reports
+}
+
+// SetShellIntegration controls whether new shells load the prompt hooks
+// that report commands and the working directory
+func (m *SessionManager) SetShellIntegration(enabled bool) {
+	m.mu.Lock()
+	m.integration = enabled
+	m.mu.Unlock()
+}
+
+// shellOptions returns the options for a shell, or the detected default
+// shell, started without a profile
+func (m *SessionManager) shellOptions(shellPath string) LaunchOptions {
+	m.mu.RLock()
+	integration := m.integration
+	m.mu.RUnlock()
+
+	return LaunchOptions{Shell: shellPath, Login: true, Integration: integration}
+}
+
+// launch starts a local shell, in the session daemon when one is set
+func (m *SessionManager) launch(opts LaunchOptions) (Backend, error) {
+	m.mu.RLock()
+	daemon := m.daemon
+	m.mu.RUnlock()
+
+	if daemon != nil {
+		return daemon.Start(opts)
+	}
+	return NewPTYSessionWithOptions(opts)
+}
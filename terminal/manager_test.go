@@ -0,0 +1,41 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplaceKeepsLaunchOptions(t *testing.T) {
+	m := NewSessionManager(context.Background(), Hooks{})
+	defer m.CloseAll()
+
+	session, err := m.CreateWithOptions(LaunchOptions{
+		Profile: "work",
+		Shell:   "/bin/sh",
+		Env:     []string{"PROFILE_MARK=from-profile"},
+	})
+	if err != nil {
+		t.Fatalf("CreateWithOptions: %v", err)
+	}
+
+	replaced, err := m.Replace(session.ID(), "")
+	if err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if info := replaced.Info(); info.ID != session.ID() || info.Profile != "work" {
+		t.Errorf("replaced session is %s with profile %q, want %s with profile work", info.ID, info.Profile, session.ID())
+	}
+
+	replaced.Write([]byte("echo \"[$PROFILE_MARK]\"\n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Contains(replaced.Scrollback().Raw(), []byte("[from-profile]")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("new shell did not get the profile's environment: %q", replaced.Scrollback().Raw())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,449 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// resetSequence (RIS) clears the screen before a backwards seek replays output
+const resetSequence = "\x1bc"
+
+// resizeSequence (XTWINOPS 8) asks the terminal to take a recording's size
+func resizeSequence(rows, cols int) string {
+	return fmt.Sprintf("\x1b[8;%d;%dt", rows, cols)
+}
+
+// castEvent is one timed event from a recording. Time is in seconds on the
+// idle-capped timeline.
+type castEvent struct {
+	Time float64
+	Code string
+	Data string
+}
+
+// PlaybackStatus describes the state of a Player
+type PlaybackStatus struct {
+	Position float64 `json:"position"`
+	Duration float64 `json:"duration"`
+	Playing  bool    `json:"playing"`
+	Speed    float64 `json:"speed"`
+	Finished bool    `json:"finished"`
+}
+
+// Player replays an asciicast recording as a read-only Backend, so a
+// recording renders through the same output path as a live shell.
+type Player struct {
+	path     string
+	title    string
+	rows     int
+	cols     int
+	events   []castEvent
+	duration float64
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	wake     chan struct{}
+	playing  bool
+	speed    float64
+	position float64   // timeline position at anchor
+	anchor   time.Time // when position was last updated while playing
+	next     int       // index of the next event to deliver
+	out      []byte    // output waiting to be read
+	closed   bool
+}
+
+// OpenPlayer loads a recording. Pauses longer than idleLimit seconds are
+// shortened to idleLimit; 0 uses the limit stored in the file, if any.
+// Playback starts immediately at the given speed.
+func OpenPlayer(path string, speed, idleLimit float64) (*Player, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	header, events, err := parseCast(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording %s: %w", filepath.Base(path), err)
+	}
+
+	if idleLimit <= 0 {
+		idleLimit = header.IdleTimeLimit
+	}
+	capIdleTime(events, idleLimit)
+
+	if speed <= 0 {
+		speed = 1
+	}
+
+	p := &Player{
+		path:    path,
+		title:   header.Title,
+		rows:    header.Height,
+		cols:    header.Width,
+		events:  events,
+		wake:    make(chan struct{}, 1),
+		playing: true,
+		speed:   speed,
+		anchor:  time.Now(),
+	}
+	if len(events) > 0 {
+		p.duration = events[len(events)-1].Time
+	}
+	p.out = p.headerSize()
+	p.cond = sync.NewCond(&p.mu)
+	go p.loop()
+
+	return p, nil
+}
+
+// parseCast reads an asciicast v1 or v2 file. Version 1 files are converted
+// to v2 events.
+func parseCast(r io.Reader) (castHeader, []castEvent, error) {
+	br := bufio.NewReader(r)
+	first, err := br.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return castHeader{}, nil, err
+	}
+
+	var header castHeader
+	if err := json.Unmarshal(first, &header); err != nil {
+		// v1 recordings are a single JSON document that may span many lines
+		rest, readErr := io.ReadAll(br)
+		if readErr != nil {
+			return castHeader{}, nil, readErr
+		}
+		return parseCastV1(append(first, rest...))
+	}
+	if header.Version != 2 {
+		return castHeader{}, nil, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+
+	var events []castEvent
+	for line := 2; ; line++ {
+		raw, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(raw)) > 0 {
+			var fields []json.RawMessage
+			var ev castEvent
+			if jsonErr := json.Unmarshal(raw, &fields); jsonErr != nil || len(fields) < 3 {
+				return castHeader{}, nil, fmt.Errorf("invalid event on line %d", line)
+			}
+			if json.Unmarshal(fields[0], &ev.Time) != nil ||
+				json.Unmarshal(fields[1], &ev.Code) != nil ||
+				json.Unmarshal(fields[2], &ev.Data) != nil {
+				return castHeader{}, nil, fmt.Errorf("invalid event on line %d", line)
+			}
+			events = append(events, ev)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return castHeader{}, nil, err
+		}
+	}
+
+	return header, events, nil
+}
+
+// parseCastV1 converts an asciicast v1 document, whose stdout frames carry
+// delays relative to the previous frame
+func parseCastV1(data []byte) (castHeader, []castEvent, error) {
+	var doc struct {
+		Version int               `json:"version"`
+		Width   int               `json:"width"`
+		Height  int               `json:"height"`
+		Title   string            `json:"title"`
+		Env     map[string]string `json:"env"`
+		Stdout  [][2]interface{}  `json:"stdout"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return castHeader{}, nil, fmt.Errorf("not an asciicast file: %w", err)
+	}
+	if doc.Version != 1 {
+		return castHeader{}, nil, fmt.Errorf("unsupported asciicast version %d", doc.Version)
+	}
+
+	header := castHeader{Version: 1, Width: doc.Width, Height: doc.Height, Title: doc.Title, Env: doc.Env}
+	events := make([]castEvent, 0, len(doc.Stdout))
+	var t float64
+	for _, frame := range doc.Stdout {
+		delay, _ := frame[0].(float64)
+		text, _ := frame[1].(string)
+		t += delay
+		events = append(events, castEvent{Time: t, Code: "o", Data: text})
+	}
+	return header, events, nil
+}
+
+// capIdleTime shortens gaps between events to at most limit seconds
+func capIdleTime(events []castEvent, limit float64) {
+	if limit <= 0 {
+		return
+	}
+	var prev, shift float64
+	for i := range events {
+		orig := events[i].Time
+		if gap := orig - prev; gap > limit {
+			shift += gap - limit
+		}
+		prev = orig
+		events[i].Time = orig - shift
+	}
+}
+
+// loop delivers events at their scheduled times
+func (p *Player) loop() {
+	for {
+		p.mu.Lock()
+		for !p.closed && (!p.playing || p.next >= len(p.events)) {
+			if p.playing {
+				// Reached the end: stay on the last frame so the user can seek back
+				p.position = p.duration
+				p.playing = false
+			}
+			p.cond.Wait()
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+
+		ev := p.events[p.next]
+		wait := time.Duration((ev.Time - p.livePosition()) / p.speed * float64(time.Second))
+		p.mu.Unlock()
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-p.wake:
+				// Play, pause, seek or speed changed: recompute the schedule
+				timer.Stop()
+				continue
+			}
+		}
+
+		p.mu.Lock()
+		if p.playing && !p.closed && p.next < len(p.events) && p.events[p.next] == ev {
+			p.position = ev.Time
+			p.anchor = time.Now()
+			p.apply(ev)
+			p.next++
+		}
+		p.mu.Unlock()
+	}
+}
+
+// livePosition returns the current timeline position. Callers hold p.mu.
+func (p *Player) livePosition() float64 {
+	if !p.playing {
+		return p.position
+	}
+	pos := p.position + time.Since(p.anchor).Seconds()*p.speed
+	if p.next < len(p.events) && pos > p.events[p.next].Time {
+		pos = p.events[p.next].Time
+	}
+	return pos
+}
+
+// apply delivers an event's output. Resize events become resize requests in
+// the output stream so they stay in order with it; input events are skipped.
+func (p *Player) apply(ev castEvent) {
+	switch ev.Code {
+	case "o":
+		p.out = append(p.out, ev.Data...)
+	case "r":
+		var rows, cols int
+		if _, err := fmt.Sscanf(ev.Data, "%dx%d", &cols, &rows); err != nil || rows <= 0 || cols <= 0 {
+			return
+		}
+		p.out = append(p.out, resizeSequence(rows, cols)...)
+	default:
+		return
+	}
+	p.cond.Broadcast()
+}
+
+// headerSize returns a resize request for the size the recording starts at
+func (p *Player) headerSize() []byte {
+	if p.rows <= 0 || p.cols <= 0 {
+		return nil
+	}
+	return []byte(resizeSequence(p.rows, p.cols))
+}
+
+// notify wakes the scheduler after a state change. Callers hold p.mu.
+func (p *Player) notify() {
+	p.cond.Broadcast()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Play resumes playback, restarting from the beginning if it had finished
+func (p *Player) Play() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.playing {
+		return
+	}
+	if p.next >= len(p.events) {
+		p.seekLocked(0)
+	}
+	p.playing = true
+	p.anchor = time.Now()
+	p.notify()
+}
+
+// Pause stops playback at the current position
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.playing {
+		return
+	}
+	p.position = p.livePosition()
+	p.playing = false
+	p.notify()
+}
+
+// Seek jumps to a position in seconds. Seeking backwards resets the screen
+// and replays all output up to the new position at once.
+func (p *Player) Seek(position float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seekLocked(position)
+	p.notify()
+}
+
+// seekLocked moves the timeline. Callers hold p.mu.
+func (p *Player) seekLocked(position float64) {
+	if position < 0 {
+		position = 0
+	}
+	if position > p.duration {
+		position = p.duration
+	}
+
+	current := p.livePosition()
+	if position < current {
+		p.out = append(p.out, resetSequence...)
+		p.out = append(p.out, p.headerSize()...)
+		p.next = 0
+	}
+	for p.next < len(p.events) && p.events[p.next].Time <= position {
+		p.apply(p.events[p.next])
+		p.next++
+	}
+	p.position = position
+	p.anchor = time.Now()
+}
+
+// SetSpeed changes the playback rate; 2 plays twice as fast
+func (p *Player) SetSpeed(speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("invalid playback speed: %v", speed)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.position = p.livePosition()
+	p.anchor = time.Now()
+	p.speed = speed
+	p.notify()
+	return nil
+}
+
+// Status returns the playback position and state
+func (p *Player) Status() PlaybackStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return PlaybackStatus{
+		Position: p.livePosition(),
+		Duration: p.duration,
+		Playing:  p.playing,
+		Speed:    p.speed,
+		Finished: p.next >= len(p.events),
+	}
+}
+
+// Size returns the terminal size the recording starts at
+func (p *Player) Size() (rows, cols int) {
+	return p.rows, p.cols
+}
+
+// Read blocks until recorded output is due
+func (p *Player) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.out) == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if len(p.out) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, p.out)
+	p.out = p.out[n:]
+	return n, nil
+}
+
+// Write rejects input since playback sessions are read-only
+func (p *Player) Write(b []byte) (int, error) {
+	return 0, errors.New("playback sessions are read-only")
+}
+
+// Resize is a no-op: the recording's layout is fixed
+func (p *Player) Resize(rows, cols int) error {
+	return nil
+}
+
+// Close stops playback
+func (p *Player) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	p.out = nil
+	p.notify()
+	return nil
+}
+
+// Wait blocks until the player is closed
+func (p *Player) Wait() (ExitStatus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for !p.closed {
+		p.cond.Wait()
+	}
+	return ExitStatus{}, nil
+}
+
+// GetShell describes the recording being played
+func (p *Player) GetShell() string {
+	if p.title != "" {
+		return p.title
+	}
+	return filepath.Base(p.path)
+}
+
+// Kind identifies Player as the playback backend
+func (p *Player) Kind() string {
+	return "playback"
+}
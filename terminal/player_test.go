@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlayerAppliesResizeEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demo.cast")
+	cast := `{"version": 2, "width": 80, "height": 24}
+[0.1, "o", "a"]
+[0.2, "r", "100x30"]
+[0.3, "o", "b"]
+`
+	if err := os.WriteFile(path, []byte(cast), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := OpenPlayer(path, 1, 0)
+	if err != nil {
+		t.Fatalf("OpenPlayer: %v", err)
+	}
+	defer p.Close()
+	p.Pause()
+	p.Seek(p.Status().Duration)
+
+	want := "\x1b[8;24;80ta\x1b[8;30;100tb"
+	buf := make([]byte, 256)
+	n, err := p.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := string(buf[:n]); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Seeking back restores the size the recording starts at
+	p.Seek(0)
+	n, _ = p.Read(buf)
+	if got := string(buf[:n]); got != resetSequence+"\x1b[8;24;80t" {
+		t.Errorf("output after seeking back = %q", got)
+	}
+}
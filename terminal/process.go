@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"fmt"
+	"time"
+)
+
+// ForegroundProcess describes the process group that currently owns a
+// session's terminal
+type ForegroundProcess struct {
+	// Pid is the process group ID, which is also the pid of its leader
+	Pid       int       `json:"pid"`
+	Name      string    `json:"name"`
+	Args      []string  `json:"args"`
+	StartedAt time.Time `json:"started_at"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+	// Shell is true when the session's shell itself is in the foreground,
+	// i.e. it is waiting at a prompt rather than running a job
+	Shell bool `json:"shell"`
+}
+
+// Busy reports whether a job other than the shell owns the terminal
+func (p ForegroundProcess) Busy() bool {
+	return !p.Shell
+}
+
+// foregroundLookup is implemented by backends that can ask the OS which
+// process group owns their terminal
+type foregroundLookup interface {
+	Foreground() (ForegroundProcess, error)
+}
+
+// Foreground returns the process group in the foreground of the session's
+// terminal
+func (s *Session) Foreground() (ForegroundProcess, error) {
+	if lookup, ok := s.backend.(foregroundLookup); ok {
+		return lookup.Foreground()
+	}
+	return ForegroundProcess{}, fmt.Errorf("foreground process of session %s is unknown", s.id)
+}
+
+// Busy reports whether a program other than the shell owns the session's
+// terminal. Sessions whose foreground cannot be determined are not busy.
+func (s *Session) Busy() bool {
+	fg, err := s.Foreground()
+	return err == nil && fg.Busy()
+}
@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// canListSessions reports whether sessionMembers can enumerate processes
+const canListSessions = true
+
+// clockTicks is the kernel's USER_HZ, the unit of process start times in
+// /proc. It is 100 on every mainstream Linux architecture.
+const clockTicks = 100
+
+// readProcess describes a process from /proc
+func readProcess(pid int) (ForegroundProcess, error) {
+	proc := ForegroundProcess{Pid: pid}
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return proc, fmt.Errorf("failed to read process %d: %w", pid, err)
+	}
+	// The command name is in parentheses and may itself contain spaces or
+	// parentheses, so the remaining fields start after the last ')'
+	open, end := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return proc, fmt.Errorf("malformed stat for process %d", pid)
+	}
+	proc.Name = string(stat[open+1 : end])
+	fields := strings.Fields(string(stat[end+1:]))
+	// fields[0] is field 3 (state); starttime is field 22
+	if len(fields) > 19 {
+		if ticks, err := strconv.ParseInt(fields[19], 10, 64); err == nil {
+			if boot, err := bootTime(); err == nil {
+				proc.StartedAt = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+				proc.ElapsedMs = time.Since(proc.StartedAt).Milliseconds()
+			}
+		}
+	}
+
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		cmdline = bytes.TrimRight(cmdline, "\x00")
+		if len(cmdline) > 0 {
+			proc.Args = strings.Split(string(cmdline), "\x00")
+		}
+	}
+	return proc, nil
+}
+
+// sessionMembers returns the pids of every live process in session sid.
+// Zombies are left out since they have already exited.
+func sessionMembers(sid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		end := bytes.LastIndexByte(stat, ')')
+		if end < 0 {
+			continue
+		}
+		// fields[0] is field 3 (state) and fields[3] is field 6 (session)
+		fields := strings.Fields(string(stat[end+1:]))
+		if len(fields) > 3 && fields[0] != "Z" && fields[3] == strconv.Itoa(sid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// bootTime reads the system boot time from /proc/stat
+func bootTime() (time.Time, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("boot time not found in /proc/stat")
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package terminal
+
+import "fmt"
+
+// canListSessions reports whether sessionMembers can enumerate processes
+const canListSessions = false
+
+// readProcess describes a process. Only Linux is supported, via /proc.
+func readProcess(pid int) (ForegroundProcess, error) {
+	return ForegroundProcess{Pid: pid}, fmt.Errorf("process details are not available on this platform")
+}
+
+// sessionMembers lists the processes in a session. Only Linux is supported.
+func sessionMembers(sid int) []int {
+	return nil
+}
@@ -3,109 +3,62 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
 
 	"github.com/creack/pty"
 )
 
-// ShellInfo represents a detected shell (for cross-platform compatibility)
-type ShellInfo struct {
-	Name        string `json:"name"`
-	Path        string `json:"path"`
-	Type        string `json:"type"`
-	Description string `json:"description"`
-}
-
 // PTYSession represents a pseudo-terminal session
 type PTYSession struct {
 	PTY    *os.File
 	cmd    *exec.Cmd
 	shell  string
 	osType string
-}
 
-// NewPTYSession creates a new PTY session with appropriate shell
-func NewPTYSession() (*PTYSession, error) {
-	shell, err := detectShell()
-	if err != nil {
-		return nil, fmt.Errorf("failed to detect shell: %w", err)
-	}
+	waitOnce   sync.Once
+	exitStatus ExitStatus
+	waitErr    error
 
-	session := &PTYSession{
-		shell:  shell,
-		osType: runtime.GOOS,
-	}
-
-	if err := session.start(); err != nil {
-		return nil, err
-	}
-
-	return session, nil
+	shutdownOnce   sync.Once
+	shutdownReport ShutdownReport
 }
 
-// detectShell determines the appropriate shell for the current OS
-func detectShell() (string, error) {
-	switch runtime.GOOS {
-	case "windows":
-		// Check for PowerShell 7, then 5, then cmd
-		if _, err := exec.LookPath("pwsh"); err == nil {
-			return "pwsh", nil
-		}
-		if _, err := exec.LookPath("powershell"); err == nil {
-			return "powershell", nil
-		}
-		return "cmd", nil
-
-	case "darwin", "linux":
-		// Check SHELL environment variable
-		if shell := os.Getenv("SHELL"); shell != "" {
-			return shell, nil
-		}
-		// Try common shells
-		for _, sh := range []string{"/bin/zsh", "/bin/bash", "/bin/sh"} {
-			if _, err := os.Stat(sh); err == nil {
-				return sh, nil
-			}
-		}
-		return "", fmt.Errorf("no shell found")
-
-	default:
-		return "", fmt.Errorf("unsupported OS: %s", runtime.GOOS)
-	}
+// NewPTYSession creates a new PTY session with appropriate shell
+func NewPTYSession() (*PTYSession, error) {
+	return NewPTYSessionWithOptions(LaunchOptions{})
 }
 
-// start initializes the PTY and shell process
-func (s *PTYSession) start() error {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "windows":
-		// On Windows, use cmd.exe with special flags for better compatibility
-		if s.shell == "cmd" {
-			cmd = exec.Command("cmd.exe", "/Q", "/K", "prompt $P$G")
-		} else {
-			cmd = exec.Command(s.shell, "-NoExit", "-Command", "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8")
-		}
-	default:
-		cmd = exec.Command(s.shell, "-l") // Login shell
-	}
-
-	// Set up environment
-	cmd.Env = os.Environ()
-
-	// Create PTY
+// startPollable starts cmd on a new PTY and returns a non-blocking master.
+// pty.Start returns a blocking file, and closing a blocking file does not
+// interrupt a Read in progress; a pollable one does, which lets Close stop
+// the session's reader even while other processes hold the PTY open.
+func startPollable(cmd *exec.Cmd) (*os.File, error) {
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to start pty: %w", err)
+		return nil, err
 	}
+	defer ptmx.Close()
 
-	s.PTY = ptmx
-	s.cmd = cmd
-
-	return nil
+	fd, err := syscall.Dup(int(ptmx.Fd()))
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), ptmx.Name()), nil
 }
 
 // Write sends input to the PTY
@@ -126,12 +79,75 @@ func (s *PTYSession) Resize(rows, cols int) error {
 	})
 }
 
-// Close terminates the PTY session
+// Close terminates the PTY session, hanging up the shell and killing any
+// processes that outlive the shutdown timeout
 func (s *PTYSession) Close() error {
-	if err := s.PTY.Close(); err != nil {
-		return err
+	s.Shutdown(shutdownTimeout)
+	_, err := s.Wait()
+	return err
+}
+
+// Wait blocks until the shell exits and returns its exit status. It is safe to call more than once.
+func (s *PTYSession) Wait() (ExitStatus, error) {
+	s.waitOnce.Do(func() {
+		err := s.cmd.Wait()
+		if s.cmd.ProcessState != nil {
+			s.exitStatus = exitStatusOf(s.cmd.ProcessState)
+		}
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			s.waitErr = err
+		}
+	})
+	return s.exitStatus, s.waitErr
+}
+
+// exitStatusOf converts a process state into an ExitStatus
+func exitStatusOf(state *os.ProcessState) ExitStatus {
+	status := ExitStatus{Code: state.ExitCode()}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.Signal = ws.Signal().String()
+	}
+	return status
+}
+
+// WorkingDir returns the current directory of the terminal's foreground
+// process, falling back to the shell's own. It reads /proc, so it only
+// succeeds on Linux.
+func (s *PTYSession) WorkingDir() (string, error) {
+	if pgrp, err := foregroundPgrp(s.PTY); err == nil && pgrp > 0 {
+		if dir, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pgrp)); err == nil {
+			return dir, nil
+		}
+	}
+	dir, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", s.cmd.Process.Pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to read working directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Foreground returns the process group that owns the terminal, which is the
+// shell itself while it waits at a prompt
+func (s *PTYSession) Foreground() (ForegroundProcess, error) {
+	pgrp, err := foregroundPgrp(s.PTY)
+	if err != nil {
+		return ForegroundProcess{}, fmt.Errorf("failed to get foreground process group: %w", err)
+	}
+	proc, err := readProcess(pgrp)
+	proc.Shell = pgrp == s.cmd.Process.Pid
+	return proc, err
+}
+
+// foregroundPgrp returns the foreground process group of the terminal,
+// as tcgetpgrp(3) does. The group ID is also the pid of its leader.
+func foregroundPgrp(tty *os.File) (int, error) {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return 0, errno
 	}
-	return s.cmd.Wait()
+	return int(pgrp), nil
 }
 
 // GetShell returns the detected shell name
@@ -139,6 +155,11 @@ func (s *PTYSession) GetShell() string {
 	return s.shell
 }
 
+// Kind identifies PTYSession as the local shell backend
+func (s *PTYSession) Kind() string {
+	return "local"
+}
+
 // GetOSType returns the operating system
 func (s *PTYSession) GetOSType() string {
 	return s.osType
@@ -149,64 +170,53 @@ func (s *PTYSession) IsWindows() bool {
 	return s.osType == "windows"
 }
 
-// GetAvailableShells returns all installed shells on the system (Unix version)
-func GetAvailableShells() []ShellInfo {
-	var shells []ShellInfo
+// NewPTYSessionWithShell creates a session with a specific shell (Unix version)
+func NewPTYSessionWithShell(shellPath string) (*PTYSession, error) {
+	return NewPTYSessionWithOptions(LaunchOptions{Shell: shellPath, Login: true})
+}
 
-	// Check for zsh
-	if path, err := exec.LookPath("zsh"); err == nil {
-		shells = append(shells, ShellInfo{
-			Name:        "Zsh",
-			Path:        path,
-			Type:        "zsh",
-			Description: "Z shell",
-		})
+// NewPTYSessionWithOptions starts a shell on a new PTY (Unix version)
+func NewPTYSessionWithOptions(opts LaunchOptions) (*PTYSession, error) {
+	shell := opts.Shell
+	if shell == "" {
+		detected, err := detectShell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect shell: %w", err)
+		}
+		shell = detected
 	}
-
-	// Check for bash
-	if path, err := exec.LookPath("bash"); err == nil {
-		shells = append(shells, ShellInfo{
-			Name:        "Bash",
-			Path:        path,
-			Type:        "bash",
-			Description: "Bourne Again Shell",
-		})
+	dir, err := launchDir(opts.Dir)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for fish
-	if path, err := exec.LookPath("fish"); err == nil {
-		shells = append(shells, ShellInfo{
-			Name:        "Fish",
-			Path:        path,
-			Type:        "fish",
-			Description: "Friendly Interactive Shell",
-		})
+	session := &PTYSession{
+		shell:  shell,
+		osType: runtime.GOOS,
 	}
 
-	// Fallback to sh
-	if path, err := exec.LookPath("sh"); err == nil {
-		shells = append(shells, ShellInfo{
-			Name:        "Sh",
-			Path:        path,
-			Type:        "sh",
-			Description: "Bourne Shell",
-		})
+	var args []string
+	if opts.Login {
+		args = []string{"-l"}
 	}
-
-	return shells
-}
-
-// NewPTYSessionWithShell creates a session with a specific shell (Unix version)
-func NewPTYSessionWithShell(shellPath string) (*PTYSession, error) {
-	session := &PTYSession{
-		shell:  shellPath,
-		osType: runtime.GOOS,
+	env := launchEnv(opts)
+	if opts.Integration {
+		integrationArgs, integrationEnv, ok, err := integrationLaunch(shell, opts.Login)
+		if err != nil {
+			// Integration is best effort; start the shell without it
+			fmt.Printf("Shell integration unavailable: %v\n", err)
+		} else if ok {
+			args = integrationArgs
+			env = append(env, integrationEnv...)
+		}
 	}
+	args = append(args, opts.Args...)
 
-	cmd := exec.Command(shellPath, "-l")
-	cmd.Env = os.Environ()
+	cmd := exec.Command(shell, args...)
+	cmd.Env = env
+	cmd.Dir = dir
 
-	ptmx, err := pty.Start(cmd)
+	ptmx, err := startPollable(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start pty: %w", err)
 	}
@@ -214,5 +224,13 @@ func NewPTYSessionWithShell(shellPath string) (*PTYSession, error) {
 	session.PTY = ptmx
 	session.cmd = cmd
 
+	// The terminal buffers the commands until the shell reads its input
+	if input := startupInput(opts.StartupCommands, "\n"); len(input) > 0 {
+		if _, err := ptmx.Write(input); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to send startup commands: %w", err)
+		}
+	}
+
 	return session, nil
 }
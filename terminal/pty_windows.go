@@ -3,6 +3,7 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,14 +14,6 @@ import (
 	"sync"
 )
 
-// ShellInfo represents a detected shell
-type ShellInfo struct {
-	Name        string `json:"name"`
-	Path        string `json:"path"`
-	Type        string `json:"type"` // cmd, powershell, pwsh, wsl, gitbash
-	Description string `json:"description"`
-}
-
 // PTYSession represents a terminal session on Windows
 type PTYSession struct {
 	cmd         *exec.Cmd
@@ -31,6 +24,9 @@ type PTYSession struct {
 	stderr      io.ReadCloser
 	outputMutex sync.Mutex
 	outputBuf   []byte
+	waitOnce    sync.Once
+	exitStatus  ExitStatus
+	waitErr     error
 }
 
 // NewPTYSession creates a new terminal session
@@ -59,13 +55,44 @@ func NewPTYSessionWithShell(shellPath string) (*PTYSession, error) {
 		osType: runtime.GOOS,
 	}
 
-	if err := session.startWithPath(shellPath); err != nil {
+	if err := session.startWithPath(shellPath, LaunchOptions{}); err != nil {
 		return nil, err
 	}
 
 	return session, nil
 }
 
+// NewPTYSessionWithOptions creates a session from launch options. Shell
+// integration and login shells are not available for Windows shells yet.
+func NewPTYSessionWithOptions(opts LaunchOptions) (*PTYSession, error) {
+	shellPath := opts.Shell
+	if shellPath == "" {
+		detected, err := detectShell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect shell: %w", err)
+		}
+		shellPath = detected
+	}
+
+	session := &PTYSession{
+		shell:  filepath.Base(shellPath),
+		osType: runtime.GOOS,
+	}
+
+	if err := session.startWithPath(shellPath, opts); err != nil {
+		return nil, err
+	}
+
+	if input := startupInput(opts.StartupCommands, "\r\n"); len(input) > 0 {
+		if _, err := session.Write(input); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to send startup commands: %w", err)
+		}
+	}
+
+	return session, nil
+}
+
 // detectShell determines the appropriate shell
 func detectShell() (string, error) {
 	// Check for PowerShell 7, then 5, then cmd
@@ -88,6 +115,7 @@ func GetAvailableShells() []ShellInfo {
 			Name:        "PowerShell 7",
 			Path:        path,
 			Type:        "pwsh",
+			Dialect:     DialectPowerShell,
 			Description: "Cross-platform PowerShell",
 		})
 	}
@@ -98,6 +126,7 @@ func GetAvailableShells() []ShellInfo {
 			Name:        "Windows PowerShell",
 			Path:        path,
 			Type:        "powershell",
+			Dialect:     DialectPowerShell,
 			Description: "Built-in Windows PowerShell",
 		})
 	}
@@ -108,6 +137,7 @@ func GetAvailableShells() []ShellInfo {
 			Name:        "Command Prompt",
 			Path:        path,
 			Type:        "cmd",
+			Dialect:     DialectCmd,
 			Description: "Classic Windows CMD",
 		})
 	}
@@ -118,6 +148,7 @@ func GetAvailableShells() []ShellInfo {
 			Name:        "WSL (Ubuntu)",
 			Path:        path,
 			Type:        "wsl",
+			Dialect:     DialectBash,
 			Description: "Windows Subsystem for Linux",
 		})
 	}
@@ -133,12 +164,14 @@ func GetAvailableShells() []ShellInfo {
 				Name:        "Git Bash",
 				Path:        path,
 				Type:        "gitbash",
+				Dialect:     DialectBash,
 				Description: "Git for Windows Bash",
 			})
 			break
 		}
 	}
 
+	probeVersions(shells)
 	return shells
 }
 
@@ -146,16 +179,22 @@ func GetAvailableShells() []ShellInfo {
 func (s *PTYSession) start() error {
 	switch s.shell {
 	case "pwsh":
-		return s.startWithPath("pwsh")
+		return s.startWithPath("pwsh", LaunchOptions{})
 	case "powershell":
-		return s.startWithPath("powershell")
+		return s.startWithPath("powershell", LaunchOptions{})
 	default:
-		return s.startWithPath("cmd")
+		return s.startWithPath("cmd", LaunchOptions{})
 	}
 }
 
-// startWithPath initializes the shell process with specific path
-func (s *PTYSession) startWithPath(shellPath string) error {
+// startWithPath initializes the shell process with specific path, applying
+// the directory, environment and extra arguments from opts
+func (s *PTYSession) startWithPath(shellPath string, opts LaunchOptions) error {
+	dir, err := launchDir(opts.Dir)
+	if err != nil {
+		return err
+	}
+
 	var cmd *exec.Cmd
 	shellType := strings.ToLower(filepath.Base(shellPath))
 
@@ -177,8 +216,11 @@ func (s *PTYSession) startWithPath(shellPath string) error {
 		cmd = exec.Command(shellPath)
 	}
 
+	cmd.Args = append(cmd.Args, opts.Args...)
+	cmd.Dir = dir
+
 	// Set up environment
-	cmd.Env = os.Environ()
+	cmd.Env = launchEnv(opts)
 
 	// Create pipes for stdin/stdout/stderr
 	stdin, err := cmd.StdinPipe()
@@ -238,7 +280,26 @@ func (s *PTYSession) Close() error {
 	if s.cmd != nil && s.cmd.Process != nil {
 		s.cmd.Process.Kill()
 	}
-	return nil
+	_, err := s.Wait()
+	return err
+}
+
+// Wait blocks until the shell exits and returns its exit status. It is safe to call more than once.
+func (s *PTYSession) Wait() (ExitStatus, error) {
+	s.waitOnce.Do(func() {
+		if s.cmd == nil {
+			return
+		}
+		err := s.cmd.Wait()
+		if s.cmd.ProcessState != nil {
+			s.exitStatus = ExitStatus{Code: s.cmd.ProcessState.ExitCode()}
+		}
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			s.waitErr = err
+		}
+	})
+	return s.exitStatus, s.waitErr
 }
 
 // GetShell returns the detected shell name
@@ -246,6 +307,11 @@ func (s *PTYSession) GetShell() string {
 	return s.shell
 }
 
+// Kind identifies PTYSession as the local shell backend
+func (s *PTYSession) Kind() string {
+	return "local"
+}
+
 // GetOSType returns the operating system
 func (s *PTYSession) GetOSType() string {
 	return s.osType
@@ -0,0 +1,292 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// frameInterval is how long output is coalesced before a frame is sent
+	frameInterval = 8 * time.Millisecond
+	// maxFrameSize flushes a frame early once this many bytes are pending
+	maxFrameSize = 64 * 1024
+	// highWater pauses PTY reads once this many bytes are unacknowledged
+	highWater = 512 * 1024
+	// lowWater resumes PTY reads once unacknowledged bytes drop to this level
+	lowWater = 128 * 1024
+	// readBufferSize is the size of each blocking PTY read
+	readBufferSize = 32 * 1024
+)
+
+// OutputEncoding selects how frame data is encoded for the frontend
+type OutputEncoding string
+
+const (
+	// EncodingUTF8 sends text. Incomplete UTF-8 sequences at the end of a frame
+	// are carried over to the next one; other invalid bytes become U+FFFD.
+	EncodingUTF8 OutputEncoding = "utf8"
+	// EncodingBase64 sends the exact bytes the shell wrote, base64 encoded
+	EncodingBase64 OutputEncoding = "base64"
+)
+
+// ParseOutputEncoding validates an encoding name from the frontend
+func ParseOutputEncoding(name string) (OutputEncoding, error) {
+	switch enc := OutputEncoding(name); enc {
+	case EncodingUTF8, EncodingBase64:
+		return enc, nil
+	default:
+		return "", fmt.Errorf("unsupported output encoding: %s", name)
+	}
+}
+
+// Frame is a batch of session output delivered to the frontend.
+// The frontend acknowledges frames by Seq once it has rendered them.
+type Frame struct {
+	Seq      uint64         `json:"seq"`
+	Data     string         `json:"data"`
+	Encoding OutputEncoding `json:"encoding"`
+	// Replay marks a frame that repeats all retained output. The frontend
+	// clears the terminal before writing it.
+	Replay bool `json:"replay,omitempty"`
+}
+
+// pumpChunk is output queued for the framer
+type pumpChunk struct {
+	data []byte
+	// replay replaces everything pushed before it
+	replay bool
+}
+
+// inflightFrame records the size of a frame awaiting acknowledgement
+type inflightFrame struct {
+	seq  uint64
+	size int
+}
+
+// outputPump coalesces PTY output into frames and applies flow control.
+// The session reader pushes chunks; a framer goroutine flushes them by time and size.
+type outputPump struct {
+	emit   func(Frame)
+	chunks chan pumpChunk
+	done   chan struct{}
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	encoding OutputEncoding
+	seq      uint64
+	unacked  int
+	inflight []inflightFrame
+	paused   bool
+	released bool
+}
+
+// newOutputPump starts a pump that delivers frames to emit
+func newOutputPump(emit func(Frame)) *outputPump {
+	p := &outputPump{
+		emit:     emit,
+		chunks:   make(chan pumpChunk, 16),
+		done:     make(chan struct{}),
+		encoding: EncodingUTF8,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	go p.loop()
+	return p
+}
+
+// push queues a chunk of output. The pump takes ownership of the slice.
+func (p *outputPump) push(chunk []byte) {
+	p.chunks <- pumpChunk{data: chunk}
+}
+
+// replay queues all retained output for a frontend that has just
+// subscribed. Output pushed before it and not yet sent is dropped, since
+// the replay contains it. Like push, it must not be called after close.
+func (p *outputPump) replay(data []byte) {
+	p.chunks <- pumpChunk{data: data, replay: true}
+}
+
+// waitForCredit blocks the reader while the frontend is too far behind.
+// Reads pause at highWater and resume once acknowledgements bring the backlog to lowWater.
+func (p *outputPump) waitForCredit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for !p.released {
+		if p.paused && p.unacked <= lowWater {
+			p.paused = false
+		} else if !p.paused && p.unacked >= highWater {
+			p.paused = true
+		}
+		if !p.paused {
+			return
+		}
+		p.cond.Wait()
+	}
+}
+
+// ack marks every frame up to and including seq as rendered
+func (p *outputPump) ack(seq uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := 0
+	for ; i < len(p.inflight) && p.inflight[i].seq <= seq; i++ {
+		p.unacked -= p.inflight[i].size
+	}
+	p.inflight = p.inflight[i:]
+	p.cond.Broadcast()
+}
+
+// reset forgets all unacknowledged frames, e.g. after the frontend reloads
+func (p *outputPump) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.unacked = 0
+	p.inflight = nil
+	p.cond.Broadcast()
+}
+
+// release disables flow control so the reader can drain the PTY during shutdown
+func (p *outputPump) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.released = true
+	p.cond.Broadcast()
+}
+
+// setEncoding changes the encoding of subsequent frames
+func (p *outputPump) setEncoding(enc OutputEncoding) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.encoding = enc
+}
+
+// close flushes pending output and waits for the framer to exit.
+// It must be called by the reader after its last push.
+func (p *outputPump) close() {
+	close(p.chunks)
+	<-p.done
+}
+
+// loop coalesces chunks into frames. A chunk arriving after an idle period is
+// sent immediately so interactive echo is not delayed; bursts are batched until
+// frameInterval elapses or maxFrameSize bytes are pending.
+func (p *outputPump) loop() {
+	defer close(p.done)
+
+	var (
+		pending   []byte
+		timer     *time.Timer
+		timerC    <-chan time.Time
+		lastFlush time.Time
+	)
+
+	flush := func(final bool) {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+		if len(pending) == 0 {
+			return
+		}
+		pending = p.send(pending, final, false)
+		lastFlush = time.Now()
+	}
+
+	for {
+		select {
+		case chunk, ok := <-p.chunks:
+			if !ok {
+				flush(true)
+				return
+			}
+			if chunk.replay {
+				if timer != nil {
+					timer.Stop()
+					timer, timerC = nil, nil
+				}
+				pending = p.send(chunk.data, false, true)
+				lastFlush = time.Now()
+				continue
+			}
+			pending = append(pending, chunk.data...)
+
+			switch {
+			case len(pending) >= maxFrameSize:
+				flush(false)
+			case timerC != nil:
+				// A flush is already scheduled
+			case time.Since(lastFlush) >= frameInterval:
+				flush(false)
+			default:
+				timer = time.NewTimer(frameInterval - time.Since(lastFlush))
+				timerC = timer.C
+			}
+
+		case <-timerC:
+			timer, timerC = nil, nil
+			flush(false)
+		}
+	}
+}
+
+// send emits one frame and records it as unacknowledged. In UTF-8 mode a
+// trailing incomplete sequence is held back and returned to be prefixed to the
+// next frame, unless this is the final flush.
+func (p *outputPump) send(data []byte, final, replay bool) []byte {
+	p.mu.Lock()
+	enc := p.encoding
+	p.mu.Unlock()
+
+	var carry []byte
+	if enc == EncodingUTF8 && !final {
+		data, carry = splitIncompleteUTF8(data)
+		// Copy so the next append cannot overwrite the emitted bytes
+		carry = append([]byte(nil), carry...)
+	}
+	if len(data) == 0 && !replay {
+		return carry
+	}
+
+	frame := Frame{Encoding: enc, Replay: replay}
+	if enc == EncodingBase64 {
+		frame.Data = base64.StdEncoding.EncodeToString(data)
+	} else {
+		frame.Data = string(data)
+	}
+
+	p.mu.Lock()
+	p.seq++
+	frame.Seq = p.seq
+	p.unacked += len(data)
+	p.inflight = append(p.inflight, inflightFrame{seq: frame.Seq, size: len(data)})
+	p.mu.Unlock()
+
+	p.emit(frame)
+	return carry
+}
+
+// splitIncompleteUTF8 splits p before a trailing multi-byte sequence that is
+// valid so far but missing continuation bytes. Complete and invalid sequences
+// are left in place.
+func splitIncompleteUTF8(p []byte) (complete, rest []byte) {
+	for i := 1; i < utf8.UTFMax && i <= len(p); i++ {
+		b := p[len(p)-i]
+		if b < utf8.RuneSelf {
+			return p, nil
+		}
+		if utf8.RuneStart(b) {
+			if utf8.FullRune(p[len(p)-i:]) {
+				return p, nil
+			}
+			return p[:len(p)-i], p[len(p)-i:]
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,158 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// castHeader is the first line of an asciicast v2 file
+type castHeader struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes a session to an asciicast v2 file. Each event is written
+// as soon as it happens so a crash loses at most the event in flight.
+type Recorder struct {
+	mu          sync.Mutex
+	file        *os.File
+	path        string
+	start       time.Time
+	recordInput bool
+	outCarry    []byte
+	inCarry     []byte
+	err         error
+}
+
+// NewRecorder creates the cast file at path and writes its header
+func NewRecorder(path string, rows, cols int, title, shell string, recordInput bool) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	r := &Recorder{
+		file:        file,
+		path:        path,
+		start:       time.Now(),
+		recordInput: recordInput,
+	}
+
+	header := castHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env: map[string]string{
+			"SHELL": shell,
+			"TERM":  "xterm-256color",
+		},
+	}
+	if err := r.writeLine(header); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Path returns the file being written
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Output records bytes written by the shell
+func (r *Recorder) Output(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outCarry = r.event("o", r.outCarry, p)
+}
+
+// Input records bytes typed by the user, if input recording is enabled
+func (r *Recorder) Input(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recordInput {
+		r.inCarry = r.event("i", r.inCarry, p)
+	}
+}
+
+// Resize records a terminal size change
+func (r *Recorder) Resize(rows, cols int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Close finishes the recording. Any incomplete UTF-8 sequence is flushed as is.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return r.err
+	}
+	if len(r.outCarry) > 0 {
+		r.writeEvent("o", string(r.outCarry))
+	}
+	if len(r.inCarry) > 0 {
+		r.writeEvent("i", string(r.inCarry))
+	}
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to close recording: %w", err)
+	}
+	r.file = nil
+	return r.err
+}
+
+// event writes carry+p as one event, holding back a trailing incomplete
+// UTF-8 sequence since asciicast event data must be valid text
+func (r *Recorder) event(code string, carry, p []byte) []byte {
+	data := append(carry, p...)
+	complete, rest := splitIncompleteUTF8(data)
+	if len(complete) > 0 {
+		r.writeEvent(code, string(complete))
+	}
+	return append([]byte(nil), rest...)
+}
+
+// writeEvent appends an [elapsed, code, data] line
+func (r *Recorder) writeEvent(code, data string) {
+	if r.file == nil || r.err != nil {
+		return
+	}
+	elapsed := float64(time.Since(r.start).Microseconds()) / 1e6
+	if err := r.writeLine([]interface{}{elapsed, code, data}); err != nil {
+		r.err = err
+	}
+}
+
+// writeLine encodes v as one line of the file
+func (r *Recorder) writeLine(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode recording event: %w", err)
+	}
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
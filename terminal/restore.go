@@ -0,0 +1,115 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// restoreBytes bounds the scrollback saved per session
+const restoreBytes = 256 * 1024
+
+// restoreReset undoes modes a program may have left set in restored
+// output: the alternate screen, mouse reporting, bracketed paste, a hidden
+// cursor and text attributes
+const restoreReset = "\x1b[?1049l\x1b[?1000l\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[?2004l\x1b[?25h\x1b[0m"
+
+// SavedSession is what is kept of a session between runs of the app
+type SavedSession struct {
+	Profile string `json:"profile,omitempty"`
+	Shell   string `json:"shell"`
+	Dir     string `json:"dir,omitempty"`
+	Title   string `json:"title,omitempty"`
+	// Scrollback is the most recent raw output, starting at a line boundary
+	Scrollback []byte    `json:"scrollback,omitempty"`
+	SavedAt    time.Time `json:"saved_at"`
+}
+
+// Save captures what is needed to recreate the session later. Only running
+// local shells are saved; remote, tmux and daemon sessions are reattached
+// instead.
+func (s *Session) Save() (SavedSession, bool) {
+	s.mu.RLock()
+	exited := s.exited
+	s.mu.RUnlock()
+	if exited || s.backend.Kind() != "local" {
+		return SavedSession{}, false
+	}
+
+	saved := SavedSession{
+		Profile: s.profile,
+		Shell:   s.backend.GetShell(),
+		Title:   s.screen.Snapshot(false).Title,
+		SavedAt: time.Now(),
+	}
+	if dir, err := s.WorkingDir(); err == nil && dir.Host == "" {
+		saved.Dir = dir.Path
+	}
+
+	raw := s.scrollback.Raw()
+	if len(raw) > restoreBytes {
+		raw = raw[len(raw)-restoreBytes:]
+		if i := bytes.IndexByte(raw, '\n'); i >= 0 {
+			raw = raw[i+1:]
+		}
+	}
+	saved.Scrollback = raw
+	return saved, true
+}
+
+// restorePrelude is the output shown before a restored session's new
+// shell: the saved scrollback, then a marker line
+func restorePrelude(saved SavedSession) []byte {
+	var b bytes.Buffer
+	b.Write(saved.Scrollback)
+	b.WriteString(restoreReset)
+	if saved.Title != "" {
+		fmt.Fprintf(&b, "\x1b]0;%s\x07", saved.Title)
+	}
+	fmt.Fprintf(&b, "\r\n\x1b[2m[restored from %s]\x1b[0m\r\n", saved.SavedAt.Local().Format("2006-01-02 15:04"))
+	return b.Bytes()
+}
+
+// SaveSessions writes saved sessions to path. Saving none removes the file.
+func SaveSessions(path string, sessions []SavedSession) error {
+	if len(sessions) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove saved sessions: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sessions: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Scrollback may hold secrets, so only the user can read it
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write sessions: %w", err)
+	}
+	return nil
+}
+
+// LoadSavedSessions reads sessions written by SaveSessions. A missing
+// file means there is nothing to restore.
+func LoadSavedSessions(path string) ([]SavedSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read saved sessions: %w", err)
+	}
+
+	var sessions []SavedSession
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse saved sessions: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,1062 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	defaultRows = 24
+	defaultCols = 80
+)
+
+// Attr is a bitmask of text attributes
+type Attr uint16
+
+const (
+	AttrBold Attr = 1 << iota
+	AttrDim
+	AttrItalic
+	AttrUnderline
+	AttrBlink
+	AttrInverse
+	AttrHidden
+	AttrStrike
+)
+
+// colorMode says how a Color's value is interpreted
+type colorMode uint8
+
+const (
+	colorDefault colorMode = iota
+	colorIndexed
+	colorRGB
+)
+
+// Color is a cell foreground or background color
+type Color struct {
+	mode  colorMode
+	value uint32
+}
+
+// String renders the color for the frontend: "" for the default color,
+// the palette index for indexed colors and #rrggbb for true color.
+func (c Color) String() string {
+	switch c.mode {
+	case colorIndexed:
+		return strconv.Itoa(int(c.value))
+	case colorRGB:
+		return fmt.Sprintf("#%06x", c.value)
+	default:
+		return ""
+	}
+}
+
+// Cell is one character position on the screen
+type Cell struct {
+	Rune rune
+	// Width is 1 for normal characters, 2 for wide ones and 0 for the
+	// placeholder cell to the right of a wide character
+	Width uint8
+	FG    Color
+	BG    Color
+	Attr  Attr
+}
+
+// blankCell returns an empty cell carrying the given background
+func blankCell(bg Color) Cell {
+	return Cell{Rune: ' ', Width: 1, BG: bg}
+}
+
+// pen is the cursor's current drawing state
+type pen struct {
+	fg   Color
+	bg   Color
+	attr Attr
+}
+
+// cursorState is what DECSC saves and DECRC restores
+type cursorState struct {
+	row, col   int
+	pen        pen
+	originMode bool
+	charsets   [2]byte
+	gl         int
+}
+
+// StyledSpan is a run of text on one line sharing the same style
+type StyledSpan struct {
+	Text      string `json:"text"`
+	FG        string `json:"fg,omitempty"`
+	BG        string `json:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Dim       bool   `json:"dim,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+	Inverse   bool   `json:"inverse,omitempty"`
+	Strike    bool   `json:"strike,omitempty"`
+}
+
+// ScreenSnapshot is a copy of the visible screen
+type ScreenSnapshot struct {
+	Rows          int            `json:"rows"`
+	Cols          int            `json:"cols"`
+	CursorRow     int            `json:"cursor_row"`
+	CursorCol     int            `json:"cursor_col"`
+	CursorVisible bool           `json:"cursor_visible"`
+	AltScreen     bool           `json:"alt_screen"`
+	Title         string         `json:"title"`
+	Lines         []string       `json:"lines"`
+	Styled        [][]StyledSpan `json:"styled,omitempty"`
+}
+
+// Screen is a headless VT100/xterm emulator. It tracks the cell grid, cursor,
+// alternate screen, colors and scroll region of a session so the backend
+// knows what is displayed without asking the frontend.
+type Screen struct {
+	mu     sync.RWMutex
+	parser *vtParser
+
+	rows, cols int
+	primary    [][]Cell
+	alternate  [][]Cell
+	grid       [][]Cell // primary or alternate
+	altActive  bool
+
+	row, col    int
+	wrapPending bool
+	pen         pen
+	saved       cursorState
+	savedAlt    cursorState
+
+	scrollTop, scrollBottom int
+	tabs                    []bool
+
+	autoWrap      bool
+	originMode    bool
+	insertMode    bool
+	cursorVisible bool
+
+	charsets [2]byte // G0 and G1 designations: 'B' for ASCII, '0' for line drawing
+	gl       int     // which of G0/G1 is invoked
+
+	title string
+
+	// windowOps lets output resize the screen with CSI 8;rows;cols t. Only
+	// recordings may do this; a live shell's size follows its PTY.
+	windowOps bool
+
+	// Directory reported by the shell with OSC 7. It describes the shell
+	// rather than the display, so a reset keeps it.
+	dirHost, dirPath string
+}
+
+// NewScreen creates a screen of the given size
+func NewScreen(rows, cols int) *Screen {
+	s := &Screen{}
+	s.parser = newVTParser(s)
+	s.reset(rows, cols)
+	return s
+}
+
+// reset returns the emulator to its power-on state
+func (s *Screen) reset(rows, cols int) {
+	if rows <= 0 {
+		rows = defaultRows
+	}
+	if cols <= 0 {
+		cols = defaultCols
+	}
+	s.rows, s.cols = rows, cols
+	s.primary = newGrid(rows, cols)
+	s.alternate = newGrid(rows, cols)
+	s.grid = s.primary
+	s.altActive = false
+	s.row, s.col = 0, 0
+	s.wrapPending = false
+	s.pen = pen{}
+	s.scrollTop, s.scrollBottom = 0, rows-1
+	s.autoWrap = true
+	s.originMode = false
+	s.insertMode = false
+	s.cursorVisible = true
+	s.charsets = [2]byte{'B', 'B'}
+	s.gl = 0
+	s.title = ""
+	s.resetTabs()
+	s.saved = s.cursorState()
+	s.savedAlt = s.saved
+}
+
+// newGrid allocates a blank grid
+func newGrid(rows, cols int) [][]Cell {
+	grid := make([][]Cell, rows)
+	for i := range grid {
+		grid[i] = newLine(cols, Color{})
+	}
+	return grid
+}
+
+// newLine allocates a blank line
+func newLine(cols int, bg Color) []Cell {
+	line := make([]Cell, cols)
+	for i := range line {
+		line[i] = blankCell(bg)
+	}
+	return line
+}
+
+// resetTabs sets a tab stop every eight columns
+func (s *Screen) resetTabs() {
+	s.tabs = make([]bool, s.cols)
+	for i := tabWidth; i < s.cols; i += tabWidth {
+		s.tabs[i] = true
+	}
+}
+
+// Write feeds PTY output into the emulator. It never fails.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.parser.parse(p)
+	return len(p), nil
+}
+
+// Resize changes the screen dimensions. When the screen shrinks, lines are
+// dropped from the top if needed to keep the cursor on screen.
+func (s *Screen) Resize(rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resize(rows, cols)
+}
+
+// AllowWindowOps lets output resize the screen, as recordings do
+func (s *Screen) AllowWindowOps() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.windowOps = true
+}
+
+// resize implements Resize. Callers hold s.mu.
+func (s *Screen) resize(rows, cols int) {
+	if rows <= 0 || cols <= 0 || (rows == s.rows && cols == s.cols) {
+		return
+	}
+
+	resize := func(grid [][]Cell, cursorRow int) ([][]Cell, int) {
+		if cursorRow >= rows {
+			drop := cursorRow - rows + 1
+			grid = grid[drop:]
+			cursorRow -= drop
+		}
+		if len(grid) > rows {
+			grid = grid[:rows]
+		}
+		for i, line := range grid {
+			switch {
+			case len(line) > cols:
+				line = line[:cols]
+				// Do not leave half of a wide character at the edge
+				if line[cols-1].Width == 2 {
+					line[cols-1] = blankCell(line[cols-1].BG)
+				}
+			case len(line) < cols:
+				line = append(line, newLine(cols-len(line), Color{})...)
+			}
+			grid[i] = line
+		}
+		for len(grid) < rows {
+			grid = append(grid, newLine(cols, Color{}))
+		}
+		return grid, cursorRow
+	}
+
+	if s.altActive {
+		s.alternate, s.row = resize(s.alternate, s.row)
+		s.primary, s.saved.row = resize(s.primary, s.saved.row)
+		s.grid = s.alternate
+	} else {
+		s.primary, s.row = resize(s.primary, s.row)
+		s.alternate, _ = resize(s.alternate, 0)
+		s.grid = s.primary
+	}
+
+	s.rows, s.cols = rows, cols
+	s.scrollTop, s.scrollBottom = 0, rows-1
+	s.row = clamp(s.row, 0, rows-1)
+	s.col = clamp(s.col, 0, cols-1)
+	s.saved.row = clamp(s.saved.row, 0, rows-1)
+	s.saved.col = clamp(s.saved.col, 0, cols-1)
+	s.wrapPending = false
+	s.resetTabs()
+}
+
+// Size returns the screen dimensions
+func (s *Screen) Size() (rows, cols int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rows, s.cols
+}
+
+// Snapshot copies the visible screen. Styled spans are included on request
+// since most callers only need the text.
+func (s *Screen) Snapshot(styled bool) ScreenSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := ScreenSnapshot{
+		Rows:          s.rows,
+		Cols:          s.cols,
+		CursorRow:     s.row,
+		CursorCol:     s.col,
+		CursorVisible: s.cursorVisible,
+		AltScreen:     s.altActive,
+		Title:         s.title,
+		Lines:         make([]string, s.rows),
+	}
+	for i, line := range s.grid {
+		snap.Lines[i] = lineText(line)
+	}
+	if styled {
+		snap.Styled = make([][]StyledSpan, s.rows)
+		for i, line := range s.grid {
+			snap.Styled[i] = lineSpans(line)
+		}
+	}
+	return snap
+}
+
+// Text returns the visible screen as plain text, one line per row
+func (s *Screen) Text() string {
+	return strings.Join(s.Snapshot(false).Lines, "\n")
+}
+
+// lineText returns the characters of a line with trailing blanks removed
+func lineText(line []Cell) string {
+	var b strings.Builder
+	for _, c := range line {
+		if c.Width == 0 {
+			continue
+		}
+		b.WriteRune(c.Rune)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// lineSpans groups a line into runs of identically styled text
+func lineSpans(line []Cell) []StyledSpan {
+	var spans []StyledSpan
+	var text strings.Builder
+	var cur Cell
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		spans = append(spans, StyledSpan{
+			Text:      text.String(),
+			FG:        cur.FG.String(),
+			BG:        cur.BG.String(),
+			Bold:      cur.Attr&AttrBold != 0,
+			Dim:       cur.Attr&AttrDim != 0,
+			Italic:    cur.Attr&AttrItalic != 0,
+			Underline: cur.Attr&AttrUnderline != 0,
+			Inverse:   cur.Attr&AttrInverse != 0,
+			Strike:    cur.Attr&AttrStrike != 0,
+		})
+		text.Reset()
+	}
+	// Trailing blanks in the default style carry no information
+	end := len(line)
+	for end > 0 && line[end-1] == blankCell(Color{}) {
+		end--
+	}
+	for _, c := range line[:end] {
+		if c.Width == 0 {
+			continue
+		}
+		if c.FG != cur.FG || c.BG != cur.BG || c.Attr != cur.Attr {
+			flush()
+			cur = c
+		}
+		text.WriteRune(c.Rune)
+	}
+	flush()
+	return spans
+}
+
+// cursorState captures the state saved by DECSC
+func (s *Screen) cursorState() cursorState {
+	return cursorState{
+		row:        s.row,
+		col:        s.col,
+		pen:        s.pen,
+		originMode: s.originMode,
+		charsets:   s.charsets,
+		gl:         s.gl,
+	}
+}
+
+// saveCursor implements DECSC
+func (s *Screen) saveCursor() {
+	if s.altActive {
+		s.savedAlt = s.cursorState()
+	} else {
+		s.saved = s.cursorState()
+	}
+}
+
+// restoreCursor implements DECRC
+func (s *Screen) restoreCursor() {
+	st := s.saved
+	if s.altActive {
+		st = s.savedAlt
+	}
+	s.row = clamp(st.row, 0, s.rows-1)
+	s.col = clamp(st.col, 0, s.cols-1)
+	s.pen = st.pen
+	s.originMode = st.originMode
+	s.charsets = st.charsets
+	s.gl = st.gl
+	s.wrapPending = false
+}
+
+// print implements vtHandler
+func (s *Screen) print(r rune) {
+	if s.charsets[s.gl] == '0' {
+		if mapped, ok := decSpecialGraphics[r]; ok {
+			r = mapped
+		}
+	}
+
+	w := runeWidth(r)
+	if w == 0 {
+		// Combining marks are not modelled
+		return
+	}
+	if w == 2 && s.cols < 2 {
+		// A wide character cannot fit, so it takes the only column
+		w = 1
+	}
+
+	if s.wrapPending && s.autoWrap {
+		s.col = 0
+		s.lineFeed()
+	}
+	s.wrapPending = false
+
+	if w == 2 && s.col == s.cols-1 {
+		if !s.autoWrap {
+			return
+		}
+		s.grid[s.row][s.col] = blankCell(s.pen.bg)
+		s.col = 0
+		s.lineFeed()
+	}
+
+	line := s.grid[s.row]
+	if s.insertMode {
+		copy(line[s.col+w:], line[s.col:])
+	}
+	s.clearWide(line, s.col)
+	line[s.col] = Cell{Rune: r, Width: uint8(w), FG: s.pen.fg, BG: s.pen.bg, Attr: s.pen.attr}
+	if w == 2 {
+		s.clearWide(line, s.col+1)
+		line[s.col+1] = Cell{Width: 0, FG: s.pen.fg, BG: s.pen.bg, Attr: s.pen.attr}
+	}
+
+	if s.col+w >= s.cols {
+		s.col = s.cols - 1
+		s.wrapPending = true
+	} else {
+		s.col += w
+	}
+}
+
+// clearWide blanks the other half of a wide character about to be overwritten at col
+func (s *Screen) clearWide(line []Cell, col int) {
+	switch {
+	case line[col].Width == 2 && col+1 < len(line):
+		line[col+1] = blankCell(line[col+1].BG)
+	case line[col].Width == 0 && col > 0:
+		line[col-1] = blankCell(line[col-1].BG)
+	}
+}
+
+// runeWidth returns how many columns r occupies
+func runeWidth(r rune) int {
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// execute implements vtHandler
+func (s *Screen) execute(c byte) {
+	switch c {
+	case '\b':
+		if s.col > 0 {
+			s.col--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.tabForward(1)
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\r':
+		s.col = 0
+		s.wrapPending = false
+	case 0x0e: // SO
+		s.gl = 1
+	case 0x0f: // SI
+		s.gl = 0
+	}
+}
+
+// lineFeed moves down one line, scrolling at the bottom of the scroll region
+func (s *Screen) lineFeed() {
+	s.wrapPending = false
+	switch {
+	case s.row == s.scrollBottom:
+		s.scrollUp(1)
+	case s.row < s.rows-1:
+		s.row++
+	}
+}
+
+// reverseIndex moves up one line, scrolling at the top of the scroll region
+func (s *Screen) reverseIndex() {
+	s.wrapPending = false
+	switch {
+	case s.row == s.scrollTop:
+		s.scrollDown(1)
+	case s.row > 0:
+		s.row--
+	}
+}
+
+// scrollUp moves the scroll region's content up by n lines
+func (s *Screen) scrollUp(n int) {
+	s.scrollRegionUp(s.scrollTop, n)
+}
+
+// scrollDown moves the scroll region's content down by n lines
+func (s *Screen) scrollDown(n int) {
+	s.scrollRegionDown(s.scrollTop, n)
+}
+
+// scrollRegionUp shifts lines top..scrollBottom up by n, blanking the bottom
+func (s *Screen) scrollRegionUp(top, n int) {
+	bottom := s.scrollBottom
+	n = clamp(n, 0, bottom-top+1)
+	copy(s.grid[top:bottom+1], s.grid[top+n:bottom+1])
+	for i := bottom - n + 1; i <= bottom; i++ {
+		s.grid[i] = newLine(s.cols, s.pen.bg)
+	}
+}
+
+// scrollRegionDown shifts lines top..scrollBottom down by n, blanking the top
+func (s *Screen) scrollRegionDown(top, n int) {
+	bottom := s.scrollBottom
+	n = clamp(n, 0, bottom-top+1)
+	copy(s.grid[top+n:bottom+1], s.grid[top:bottom+1-n])
+	for i := top; i < top+n; i++ {
+		s.grid[i] = newLine(s.cols, s.pen.bg)
+	}
+}
+
+// tabForward moves to the nth next tab stop
+func (s *Screen) tabForward(n int) {
+	for ; n > 0 && s.col < s.cols-1; n-- {
+		s.col++
+		for s.col < s.cols-1 && !s.tabs[s.col] {
+			s.col++
+		}
+	}
+	s.wrapPending = false
+}
+
+// tabBackward moves to the nth previous tab stop
+func (s *Screen) tabBackward(n int) {
+	for ; n > 0 && s.col > 0; n-- {
+		s.col--
+		for s.col > 0 && !s.tabs[s.col] {
+			s.col--
+		}
+	}
+	s.wrapPending = false
+}
+
+// moveTo positions the cursor, honoring origin mode
+func (s *Screen) moveTo(row, col int) {
+	if s.originMode {
+		row = clamp(row+s.scrollTop, s.scrollTop, s.scrollBottom)
+	}
+	s.row = clamp(row, 0, s.rows-1)
+	s.col = clamp(col, 0, s.cols-1)
+	s.wrapPending = false
+}
+
+// moveVertical moves the cursor within the scroll region if it starts inside it
+func (s *Screen) moveVertical(delta int) {
+	top, bottom := 0, s.rows-1
+	if s.row >= s.scrollTop && s.row <= s.scrollBottom {
+		top, bottom = s.scrollTop, s.scrollBottom
+	}
+	s.row = clamp(s.row+delta, top, bottom)
+	s.wrapPending = false
+}
+
+// eraseCells blanks columns from..to (inclusive) of a row
+func (s *Screen) eraseCells(row, from, to int) {
+	line := s.grid[row]
+	from = clamp(from, 0, s.cols-1)
+	to = clamp(to, 0, s.cols-1)
+	for i := from; i <= to; i++ {
+		line[i] = blankCell(s.pen.bg)
+	}
+	// Do not leave orphaned halves of wide characters at the edges
+	if from > 0 && line[from-1].Width == 2 {
+		line[from-1] = blankCell(line[from-1].BG)
+	}
+	if to+1 < s.cols && line[to+1].Width == 0 {
+		line[to+1] = blankCell(line[to+1].BG)
+	}
+}
+
+// eraseInDisplay implements ED
+func (s *Screen) eraseInDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseCells(s.row, s.col, s.cols-1)
+		for i := s.row + 1; i < s.rows; i++ {
+			s.eraseCells(i, 0, s.cols-1)
+		}
+	case 1:
+		for i := 0; i < s.row; i++ {
+			s.eraseCells(i, 0, s.cols-1)
+		}
+		s.eraseCells(s.row, 0, s.col)
+	case 2, 3:
+		for i := 0; i < s.rows; i++ {
+			s.eraseCells(i, 0, s.cols-1)
+		}
+	}
+	s.wrapPending = false
+}
+
+// eraseInLine implements EL
+func (s *Screen) eraseInLine(mode int) {
+	switch mode {
+	case 0:
+		s.eraseCells(s.row, s.col, s.cols-1)
+	case 1:
+		s.eraseCells(s.row, 0, s.col)
+	case 2:
+		s.eraseCells(s.row, 0, s.cols-1)
+	}
+	s.wrapPending = false
+}
+
+// insertLines implements IL
+func (s *Screen) insertLines(n int) {
+	if s.row < s.scrollTop || s.row > s.scrollBottom {
+		return
+	}
+	s.scrollRegionDown(s.row, n)
+	s.col = 0
+	s.wrapPending = false
+}
+
+// deleteLines implements DL
+func (s *Screen) deleteLines(n int) {
+	if s.row < s.scrollTop || s.row > s.scrollBottom {
+		return
+	}
+	s.scrollRegionUp(s.row, n)
+	s.col = 0
+	s.wrapPending = false
+}
+
+// insertChars implements ICH
+func (s *Screen) insertChars(n int) {
+	line := s.grid[s.row]
+	n = clamp(n, 0, s.cols-s.col)
+	copy(line[s.col+n:], line[s.col:])
+	for i := s.col; i < s.col+n; i++ {
+		line[i] = blankCell(s.pen.bg)
+	}
+	s.wrapPending = false
+}
+
+// deleteChars implements DCH
+func (s *Screen) deleteChars(n int) {
+	line := s.grid[s.row]
+	n = clamp(n, 0, s.cols-s.col)
+	copy(line[s.col:], line[s.col+n:])
+	for i := s.cols - n; i < s.cols; i++ {
+		line[i] = blankCell(s.pen.bg)
+	}
+	s.wrapPending = false
+}
+
+// setAltScreen switches between the primary and alternate screens.
+// Mode 1049 also saves and restores the cursor and clears the alternate screen.
+func (s *Screen) setAltScreen(on bool, mode int) {
+	if on == s.altActive {
+		return
+	}
+	if on {
+		if mode == 1049 {
+			s.saved = s.cursorState()
+		}
+		s.altActive = true
+		s.grid = s.alternate
+		if mode != 47 {
+			for i := 0; i < s.rows; i++ {
+				s.grid[i] = newLine(s.cols, Color{})
+			}
+		}
+	} else {
+		s.altActive = false
+		s.grid = s.primary
+		if mode == 1049 {
+			s.restoreCursor()
+		}
+	}
+	s.wrapPending = false
+}
+
+// setMode implements SM/RM and DECSET/DECRST
+func (s *Screen) setMode(params []int, private byte, on bool) {
+	for _, p := range params {
+		if private == '?' {
+			switch p {
+			case 6:
+				s.originMode = on
+				s.moveTo(0, 0)
+			case 7:
+				s.autoWrap = on
+			case 25:
+				s.cursorVisible = on
+			case 47, 1047, 1049:
+				s.setAltScreen(on, p)
+			case 1048:
+				if on {
+					s.saveCursor()
+				} else {
+					s.restoreCursor()
+				}
+			}
+			continue
+		}
+		if p == 4 {
+			s.insertMode = on
+		}
+	}
+}
+
+// setScrollRegion implements DECSTBM
+func (s *Screen) setScrollRegion(top, bottom int) {
+	if bottom <= 0 || bottom > s.rows {
+		bottom = s.rows
+	}
+	if top <= 0 {
+		top = 1
+	}
+	if top >= bottom {
+		return
+	}
+	s.scrollTop, s.scrollBottom = top-1, bottom-1
+	s.moveTo(0, 0)
+}
+
+// csiDispatch implements vtHandler
+func (s *Screen) csiDispatch(params []int, private byte, intermediates []byte, final byte) {
+	if len(intermediates) > 0 {
+		// DECSCUSR, DECSTR and friends do not affect the model
+		if intermediates[0] == '!' && final == 'p' {
+			s.softReset()
+		}
+		return
+	}
+
+	arg := func(i, def int) int {
+		if i < len(params) && params[i] != 0 {
+			return params[i]
+		}
+		return def
+	}
+
+	if private != 0 && final != 'h' && final != 'l' {
+		return
+	}
+
+	switch final {
+	case '@':
+		s.insertChars(arg(0, 1))
+	case 'A':
+		s.moveVertical(-arg(0, 1))
+	case 'B', 'e':
+		s.moveVertical(arg(0, 1))
+	case 'C', 'a':
+		s.col = clamp(s.col+arg(0, 1), 0, s.cols-1)
+		s.wrapPending = false
+	case 'D':
+		s.col = clamp(s.col-arg(0, 1), 0, s.cols-1)
+		s.wrapPending = false
+	case 'E':
+		s.moveVertical(arg(0, 1))
+		s.col = 0
+	case 'F':
+		s.moveVertical(-arg(0, 1))
+		s.col = 0
+	case 'G', '`':
+		s.col = clamp(arg(0, 1)-1, 0, s.cols-1)
+		s.wrapPending = false
+	case 'H', 'f':
+		s.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'I':
+		s.tabForward(arg(0, 1))
+	case 'J':
+		s.eraseInDisplay(arg(0, 0))
+	case 'K':
+		s.eraseInLine(arg(0, 0))
+	case 'L':
+		s.insertLines(arg(0, 1))
+	case 'M':
+		s.deleteLines(arg(0, 1))
+	case 'P':
+		s.deleteChars(arg(0, 1))
+	case 'S':
+		s.scrollUp(arg(0, 1))
+	case 'T':
+		s.scrollDown(arg(0, 1))
+	case 'X':
+		s.eraseCells(s.row, s.col, s.col+arg(0, 1)-1)
+		s.wrapPending = false
+	case 'Z':
+		s.tabBackward(arg(0, 1))
+	case 'd':
+		row := arg(0, 1) - 1
+		if s.originMode {
+			row += s.scrollTop
+		}
+		s.row = clamp(row, 0, s.rows-1)
+		s.wrapPending = false
+	case 'g':
+		switch arg(0, 0) {
+		case 0:
+			s.tabs[s.col] = false
+		case 3:
+			s.tabs = make([]bool, s.cols)
+		}
+	case 'h':
+		s.setMode(params, private, true)
+	case 'l':
+		s.setMode(params, private, false)
+	case 'm':
+		s.selectGraphicRendition(params)
+	case 'r':
+		s.setScrollRegion(arg(0, 1), arg(1, s.rows))
+	case 's':
+		s.saveCursor()
+	case 't':
+		// XTWINOPS: only the resize request changes the model
+		if s.windowOps && arg(0, 0) == 8 {
+			s.resize(arg(1, s.rows), arg(2, s.cols))
+		}
+	case 'u':
+		s.restoreCursor()
+	}
+}
+
+// softReset implements DECSTR
+func (s *Screen) softReset() {
+	s.pen = pen{}
+	s.cursorVisible = true
+	s.autoWrap = true
+	s.originMode = false
+	s.insertMode = false
+	s.scrollTop, s.scrollBottom = 0, s.rows-1
+	s.charsets = [2]byte{'B', 'B'}
+	s.gl = 0
+	s.wrapPending = false
+}
+
+// selectGraphicRendition implements SGR
+func (s *Screen) selectGraphicRendition(params []int) {
+	if len(params) == 0 {
+		s.pen = pen{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch {
+		case p == 0:
+			s.pen = pen{}
+		case p == 1:
+			s.pen.attr |= AttrBold
+		case p == 2:
+			s.pen.attr |= AttrDim
+		case p == 3:
+			s.pen.attr |= AttrItalic
+		case p == 4:
+			s.pen.attr |= AttrUnderline
+		case p == 5 || p == 6:
+			s.pen.attr |= AttrBlink
+		case p == 7:
+			s.pen.attr |= AttrInverse
+		case p == 8:
+			s.pen.attr |= AttrHidden
+		case p == 9:
+			s.pen.attr |= AttrStrike
+		case p == 21 || p == 22:
+			s.pen.attr &^= AttrBold | AttrDim
+		case p == 23:
+			s.pen.attr &^= AttrItalic
+		case p == 24:
+			s.pen.attr &^= AttrUnderline
+		case p == 25:
+			s.pen.attr &^= AttrBlink
+		case p == 27:
+			s.pen.attr &^= AttrInverse
+		case p == 28:
+			s.pen.attr &^= AttrHidden
+		case p == 29:
+			s.pen.attr &^= AttrStrike
+		case p >= 30 && p <= 37:
+			s.pen.fg = Color{mode: colorIndexed, value: uint32(p - 30)}
+		case p == 38:
+			s.pen.fg, i = extendedColor(params, i)
+		case p == 39:
+			s.pen.fg = Color{}
+		case p >= 40 && p <= 47:
+			s.pen.bg = Color{mode: colorIndexed, value: uint32(p - 40)}
+		case p == 48:
+			s.pen.bg, i = extendedColor(params, i)
+		case p == 49:
+			s.pen.bg = Color{}
+		case p >= 90 && p <= 97:
+			s.pen.fg = Color{mode: colorIndexed, value: uint32(p - 90 + 8)}
+		case p >= 100 && p <= 107:
+			s.pen.bg = Color{mode: colorIndexed, value: uint32(p - 100 + 8)}
+		}
+	}
+}
+
+// extendedColor parses the 38/48 forms "5;n" and "2;r;g;b" starting after params[i].
+// It returns the color and the index of the last parameter consumed.
+func extendedColor(params []int, i int) (Color, int) {
+	if i+1 >= len(params) {
+		return Color{}, i
+	}
+	switch params[i+1] {
+	case 5:
+		if i+2 < len(params) {
+			return Color{mode: colorIndexed, value: uint32(params[i+2] & 0xff)}, i + 2
+		}
+	case 2:
+		if i+4 < len(params) {
+			r, g, b := params[i+2]&0xff, params[i+3]&0xff, params[i+4]&0xff
+			return Color{mode: colorRGB, value: uint32(r<<16 | g<<8 | b)}, i + 4
+		}
+	}
+	return Color{}, len(params) - 1
+}
+
+// escDispatch implements vtHandler
+func (s *Screen) escDispatch(intermediates []byte, final byte) {
+	if len(intermediates) > 0 {
+		switch intermediates[0] {
+		case '(':
+			s.charsets[0] = final
+		case ')':
+			s.charsets[1] = final
+		case '#':
+			if final == '8' {
+				s.alignmentTest()
+			}
+		}
+		return
+	}
+
+	switch final {
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.col = 0
+		s.lineFeed()
+	case 'H':
+		s.tabs[s.col] = true
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset(s.rows, s.cols)
+	}
+}
+
+// alignmentTest implements DECALN, filling the screen with E
+func (s *Screen) alignmentTest() {
+	for _, line := range s.grid {
+		for i := range line {
+			line[i] = Cell{Rune: 'E', Width: 1}
+		}
+	}
+	s.scrollTop, s.scrollBottom = 0, s.rows-1
+	s.moveTo(0, 0)
+}
+
+// oscDispatch implements vtHandler
+func (s *Screen) oscDispatch(data []byte) {
+	cmd, arg, _ := strings.Cut(string(data), ";")
+	switch cmd {
+	case "0", "2":
+		s.title = arg
+	case "7":
+		if host, path, ok := parseDirReport(arg); ok {
+			s.dirHost, s.dirPath = host, path
+		}
+	}
+}
+
+// ReportedDir returns the working directory last reported with OSC 7, if any
+func (s *Screen) ReportedDir() (host, path string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dirHost, s.dirPath, s.dirPath != ""
+}
+
+// decSpecialGraphics maps the DEC line-drawing character set onto Unicode
+var decSpecialGraphics = map[rune]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌',
+	'm': '└', 'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽',
+	't': '├', 'u': '┤', 'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥',
+	'{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+// clamp limits v to the range [lo, hi]
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
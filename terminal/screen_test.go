@@ -0,0 +1,40 @@
+package terminal
+
+import "testing"
+
+func TestScreenWideRuneInOneColumn(t *testing.T) {
+	s := NewScreen(2, 1)
+	s.Resize(2, 1)
+	if _, err := s.Write([]byte("中a")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	snap := s.Snapshot(false)
+	if snap.Lines[0] != "中" || snap.Lines[1] != "a" {
+		t.Errorf("lines = %q, want [\"中\" \"a\"]", snap.Lines)
+	}
+}
+
+func TestScreenWideRuneWraps(t *testing.T) {
+	s := NewScreen(2, 3)
+	s.Write([]byte("ab中"))
+
+	snap := s.Snapshot(false)
+	if snap.Lines[0] != "ab" || snap.Lines[1] != "中" {
+		t.Errorf("lines = %q, want [\"ab\" \"中\"]", snap.Lines)
+	}
+}
+
+func TestScreenResizeRequest(t *testing.T) {
+	s := NewScreen(24, 80)
+	s.Write([]byte("\x1b[8;10;40t"))
+	if rows, cols := s.Size(); rows != 24 || cols != 80 {
+		t.Errorf("live screen resized to %dx%d", cols, rows)
+	}
+
+	s.AllowWindowOps()
+	s.Write([]byte("\x1b[8;10;40t"))
+	if rows, cols := s.Size(); rows != 10 || cols != 40 {
+		t.Errorf("size = %dx%d, want 40x10", cols, rows)
+	}
+}
@@ -0,0 +1,329 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// scrollbackBytes bounds the raw output kept per session
+	scrollbackBytes = 2 * 1024 * 1024
+	// scrollbackLines bounds the plain-text lines kept per session
+	scrollbackLines = 10000
+	// maxLineRunes bounds a plain-text line; longer output without a
+	// newline wraps onto the next line
+	maxLineRunes = 4096
+	// maxSearchMatches caps the number of results returned by Search
+	maxSearchMatches = 1000
+	// tabWidth is the distance between tab stops in the plain-text view
+	tabWidth = 8
+)
+
+// stripState tracks where the ANSI stripper is within an escape sequence
+type stripState int
+
+const (
+	stripGround stripState = iota
+	stripEscape
+	stripEscapeIntermediate
+	stripCSI
+	stripString    // OSC, DCS, SOS, PM and APC payloads
+	stripStringEsc // ESC seen inside a string, expecting the ST terminator
+)
+
+// LineRange is a window of plain-text scrollback lines. Line numbers are
+// absolute: they keep increasing as old lines are evicted.
+type LineRange struct {
+	// First is the number of the first line in Lines
+	First int64 `json:"first"`
+	// Oldest is the number of the oldest line still retained
+	Oldest int64 `json:"oldest"`
+	// Total is one past the number of the newest line, which may be incomplete
+	Total int64    `json:"total"`
+	Lines []string `json:"lines"`
+}
+
+// SearchOptions controls how Search matches the query
+type SearchOptions struct {
+	Regex         bool `json:"regex"`
+	CaseSensitive bool `json:"case_sensitive"`
+}
+
+// Match is a search hit. Start and End are rune offsets within the line.
+type Match struct {
+	Line  int64  `json:"line"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Scrollback is a bounded per-session history of output. It keeps the raw
+// bytes in a ring buffer and an ANSI-stripped plain-text view split into lines.
+type Scrollback struct {
+	mu sync.RWMutex
+
+	raw     []byte
+	rawHead int // next write position in raw
+	rawFull bool
+
+	lines     []string // ring of completed lines
+	lineHead  int      // index of the oldest line in lines
+	lineCount int
+	evicted   int64 // number of lines dropped from the front
+
+	current []rune // line being built; \r moves col back to overwrite it
+	col     int
+	state   stripState
+	partial []byte // incomplete UTF-8 sequence from the previous write
+}
+
+// NewScrollback creates an empty scrollback buffer
+func NewScrollback() *Scrollback {
+	return &Scrollback{
+		raw:   make([]byte, scrollbackBytes),
+		lines: make([]string, scrollbackLines),
+	}
+}
+
+// Write records a chunk of output. It never fails.
+func (b *Scrollback) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.writeRaw(p)
+
+	data := p
+	if len(b.partial) > 0 {
+		data = append(b.partial, p...)
+		b.partial = nil
+	}
+	for len(data) > 0 {
+		c := data[0]
+		if b.state != stripGround || c < utf8.RuneSelf {
+			b.strip(c)
+			data = data[1:]
+			continue
+		}
+		if !utf8.FullRune(data) {
+			b.partial = append([]byte(nil), data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		b.put(r)
+		data = data[size:]
+	}
+
+	return len(p), nil
+}
+
+// writeRaw appends p to the raw ring buffer
+func (b *Scrollback) writeRaw(p []byte) {
+	if len(p) >= len(b.raw) {
+		copy(b.raw, p[len(p)-len(b.raw):])
+		b.rawHead = 0
+		b.rawFull = true
+		return
+	}
+	n := copy(b.raw[b.rawHead:], p)
+	if n < len(p) {
+		copy(b.raw, p[n:])
+		b.rawFull = true
+	}
+	b.rawHead = (b.rawHead + len(p)) % len(b.raw)
+	if b.rawHead == 0 && len(p) > 0 {
+		b.rawFull = true
+	}
+}
+
+// strip advances the escape-sequence state machine by one byte
+func (b *Scrollback) strip(c byte) {
+	switch b.state {
+	case stripGround:
+		switch c {
+		case 0x1b:
+			b.state = stripEscape
+		case '\n':
+			b.commitLine()
+		case '\r':
+			b.col = 0
+		case '\b':
+			if b.col > 0 {
+				b.col--
+			}
+		case '\t':
+			for next := (b.col/tabWidth + 1) * tabWidth; b.col < next; {
+				b.put(' ')
+			}
+		default:
+			if c >= 0x20 && c != 0x7f {
+				b.put(rune(c))
+			}
+		}
+
+	case stripEscape:
+		switch {
+		case c == '[':
+			b.state = stripCSI
+		case c == ']' || c == 'P' || c == 'X' || c == '^' || c == '_':
+			b.state = stripString
+		case c >= 0x20 && c <= 0x2f:
+			// Charset designation and similar: one more byte follows
+			b.state = stripEscapeIntermediate
+		default:
+			b.state = stripGround
+		}
+
+	case stripEscapeIntermediate:
+		if c < 0x20 || c > 0x2f {
+			b.state = stripGround
+		}
+
+	case stripCSI:
+		if c >= 0x40 && c <= 0x7e {
+			b.state = stripGround
+		}
+
+	case stripString:
+		switch c {
+		case 0x07:
+			b.state = stripGround
+		case 0x1b:
+			b.state = stripStringEsc
+		}
+
+	case stripStringEsc:
+		if c == '\\' {
+			b.state = stripGround
+		} else {
+			b.state = stripString
+		}
+	}
+}
+
+// put writes a printable rune at the current column
+func (b *Scrollback) put(r rune) {
+	if b.col >= maxLineRunes {
+		b.commitLine()
+	}
+	if b.col < len(b.current) {
+		b.current[b.col] = r
+	} else {
+		for len(b.current) < b.col {
+			b.current = append(b.current, ' ')
+		}
+		b.current = append(b.current, r)
+	}
+	b.col++
+}
+
+// commitLine moves the current line into the ring, evicting the oldest if full
+func (b *Scrollback) commitLine() {
+	line := strings.TrimRight(string(b.current), " ")
+	b.current = b.current[:0]
+	b.col = 0
+
+	if b.lineCount < len(b.lines) {
+		b.lines[(b.lineHead+b.lineCount)%len(b.lines)] = line
+		b.lineCount++
+		return
+	}
+	b.lines[b.lineHead] = line
+	b.lineHead = (b.lineHead + 1) % len(b.lines)
+	b.evicted++
+}
+
+// Raw returns the retained raw output. Once the ring has wrapped, output
+// before the first newline is dropped so replay starts on a line boundary.
+func (b *Scrollback) Raw() []byte {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.rawFull {
+		return append([]byte(nil), b.raw[:b.rawHead]...)
+	}
+	out := make([]byte, 0, len(b.raw))
+	out = append(out, b.raw[b.rawHead:]...)
+	out = append(out, b.raw[:b.rawHead]...)
+	if i := bytes.IndexByte(out, '\n'); i >= 0 {
+		out = out[i+1:]
+	}
+	return out
+}
+
+// Lines returns up to count plain-text lines starting at absolute line number
+// start. The newest line is included even if it has not been terminated yet.
+func (b *Scrollback) Lines(start int64, count int) LineRange {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	r := LineRange{
+		Oldest: b.evicted,
+		Total:  b.evicted + int64(b.lineCount) + 1,
+		Lines:  []string{},
+	}
+	if start < r.Oldest {
+		start = r.Oldest
+	}
+	r.First = start
+
+	for n := start; n < r.Total && len(r.Lines) < count; n++ {
+		r.Lines = append(r.Lines, b.line(n))
+	}
+	return r
+}
+
+// line returns the text of absolute line n, which must be retained
+func (b *Scrollback) line(n int64) string {
+	i := int(n - b.evicted)
+	if i == b.lineCount {
+		return strings.TrimRight(string(b.current), " ")
+	}
+	return b.lines[(b.lineHead+i)%len(b.lines)]
+}
+
+// Search finds query in the plain-text view, oldest match first
+func (b *Scrollback) Search(query string, opts SearchOptions) ([]Match, error) {
+	if query == "" {
+		return []Match{}, nil
+	}
+
+	pattern := query
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(query)
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	matches := []Match{}
+	total := b.evicted + int64(b.lineCount) + 1
+	for n := b.evicted; n < total; n++ {
+		text := b.line(n)
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			matches = append(matches, Match{
+				Line:  n,
+				Start: utf8.RuneCountInString(text[:loc[0]]),
+				End:   utf8.RuneCountInString(text[:loc[1]]),
+				Text:  text[loc[0]:loc[1]],
+			})
+			if len(matches) >= maxSearchMatches {
+				return matches, nil
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,38 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrollbackWrapsLongLines(t *testing.T) {
+	b := NewScrollback()
+	b.Write([]byte(strings.Repeat("y", 3*maxLineRunes+10)))
+	b.Write([]byte("\n"))
+
+	r := b.Lines(0, 4)
+	if len(r.Lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(r.Lines))
+	}
+	for i, line := range r.Lines[:3] {
+		if len(line) != maxLineRunes {
+			t.Errorf("line %d has %d runes, want %d", i, len(line), maxLineRunes)
+		}
+	}
+	if r.Lines[3] != strings.Repeat("y", 10) {
+		t.Errorf("last line = %q", r.Lines[3])
+	}
+}
+
+func TestScrollbackCarriageReturnOverwrites(t *testing.T) {
+	b := NewScrollback()
+	for i := 0; i < 10000; i++ {
+		b.Write([]byte("\rprogress 50%"))
+	}
+	b.Write([]byte("\rdone\n"))
+
+	r := b.Lines(0, 1)
+	if r.Total != 2 || r.Lines[0] != "doneress 50%" {
+		t.Errorf("lines = %q of %d, want one line \"doneress 50%%\"", r.Lines, r.Total)
+	}
+}
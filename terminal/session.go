@@ -0,0 +1,384 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// exitDrainTimeout is how long output is still read after the shell exits
+const exitDrainTimeout = 500 * time.Millisecond
+
+// ExitStatus describes how a session's shell terminated
+type ExitStatus struct {
+	Code   int    `json:"code"`
+	Signal string `json:"signal,omitempty"`
+}
+
+// Hooks receive session output and lifecycle events from a SessionManager.
+// Output frames are delivered in order, and Exit is only called after the last frame.
+type Hooks struct {
+	// Output receives coalesced output frames, which the frontend should acknowledge
+	Output func(sessionID string, frame Frame)
+	// Exit is called once the shell has terminated and its output is drained.
+	Exit func(sessionID string, status ExitStatus)
+	// Command is called when the shell reports that a command has finished
+	Command func(sessionID string, record CommandRecord)
+}
+
+// Backend is the process or stream behind a Session. PTYSession is the
+// local shell backend; other backends replay or relay terminal output.
+type Backend interface {
+	io.ReadWriter
+	Resize(rows, cols int) error
+	// Close ends the backend and unblocks any pending Read
+	Close() error
+	// Wait blocks until the backend has ended. It is safe to call more than once.
+	Wait() (ExitStatus, error)
+	GetShell() string
+	// Kind names the backend type for the frontend, e.g. "local" or "playback"
+	Kind() string
+}
+
+// killer is implemented by backends whose processes outlive Close, such
+// as tmux panes, so that closing a session on purpose can still end them
+type killer interface {
+	Kill() error
+}
+
+// Session is a terminal session tracked by a SessionManager
+type Session struct {
+	id         string
+	backend    Backend
+	profile    string
+	createdAt  time.Time
+	hooks      Hooks
+	pump       *outputPump
+	scrollback *Scrollback
+	screen     *Screen
+	commands   *CommandTracker
+	// prelude is output shown before the backend's, such as restored scrollback
+	prelude []byte
+	// outMu orders output with replays to a newly subscribed frontend
+	outMu sync.Mutex
+	// outDone is set once the reader has pushed its last output
+	outDone bool
+	// launch holds the options a local shell was started with, so that
+	// restarting it starts the same shell; nil for other backends
+	launch *LaunchOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.RWMutex
+	exited   bool
+	status   ExitStatus
+	recorder *Recorder
+	shutdown ShutdownReport
+}
+
+// SessionInfo describes a session for the frontend
+type SessionInfo struct {
+	ID    string `json:"id"`
+	Shell string `json:"shell"`
+	Kind  string `json:"kind"`
+	// Profile names the shell profile the session was launched from, if any
+	Profile   string      `json:"profile,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Running   bool        `json:"running"`
+	Exit      *ExitStatus `json:"exit,omitempty"`
+	// Foreground is the job owning the terminal, when it can be determined
+	Foreground *ForegroundProcess `json:"foreground,omitempty"`
+}
+
+// newSession wraps a started backend. The session ends when ctx is cancelled or the shell exits.
+func newSession(ctx context.Context, id string, backend Backend, createdAt time.Time, hooks Hooks) *Session {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Session{
+		id:         id,
+		backend:    backend,
+		createdAt:  createdAt,
+		hooks:      hooks,
+		scrollback: NewScrollback(),
+		screen:     NewScreen(defaultRows, defaultCols),
+		commands:   NewCommandTracker(),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	// Recordings carry their own resizes in the output stream
+	if backend.Kind() == "playback" {
+		s.screen.AllowWindowOps()
+	}
+	s.pump = newOutputPump(func(frame Frame) {
+		if hooks.Output != nil {
+			hooks.Output(id, frame)
+		}
+	})
+	return s
+}
+
+// run owns the session's only reader. It blocks on PTY reads, pausing while the
+// frontend is behind on acknowledgements, and returns once the PTY is closed and
+// the shell has been reaped.
+func (s *Session) run() {
+	defer close(s.done)
+
+	// Closing the PTY is what unblocks the reader below
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		<-s.ctx.Done()
+		s.pump.release()
+		report := ShutdownReport{SessionID: s.id}
+		if sd, ok := s.backend.(shutdowner); ok {
+			report = sd.Shutdown(shutdownTimeout)
+			report.SessionID = s.id
+		}
+		s.backend.Close()
+		s.mu.Lock()
+		s.shutdown = report
+		s.mu.Unlock()
+	}()
+
+	// Background jobs can keep the PTY open after the shell exits, so end the
+	// session once the shell is gone and its last output has had time to drain
+	go func() {
+		s.backend.Wait()
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(exitDrainTimeout):
+			s.cancel()
+		}
+	}()
+
+	// The frontend usually subscribes after this, and sees the prelude
+	// when ResetFlow replays the scrollback
+	if len(s.prelude) > 0 {
+		s.screen.Write(s.prelude)
+		s.commands.Skip(s.prelude)
+		s.output(s.prelude)
+	}
+
+	for {
+		s.pump.waitForCredit()
+
+		buf := make([]byte, readBufferSize)
+		n, err := s.backend.Read(buf)
+		if n > 0 {
+			s.screen.Write(buf[:n])
+			finished := s.commands.Write(buf[:n])
+			if rec := s.activeRecorder(); rec != nil {
+				rec.Output(buf[:n])
+			}
+			s.output(buf[:n])
+			for _, record := range finished {
+				if s.hooks.Command != nil {
+					s.hooks.Command(s.id, record)
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	// Deliver any buffered output before reporting the exit
+	s.outMu.Lock()
+	s.outDone = true
+	s.outMu.Unlock()
+	s.pump.close()
+
+	status, _ := s.backend.Wait()
+	s.mu.Lock()
+	s.exited = true
+	s.status = status
+	rec := s.recorder
+	s.recorder = nil
+	s.mu.Unlock()
+
+	if rec != nil {
+		rec.Close()
+	}
+
+	s.cancel()
+
+	if s.hooks.Exit != nil {
+		s.hooks.Exit(s.id, status)
+	}
+
+	// Jobs left behind by the shell may still be shutting down
+	<-closed
+}
+
+// output records a chunk in the scrollback and sends it to the frontend
+func (s *Session) output(chunk []byte) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.scrollback.Write(chunk)
+	s.pump.push(chunk)
+}
+
+// stop cancels the session, waits for its reader to finish and reports how
+// its processes ended
+func (s *Session) stop() ShutdownReport {
+	s.cancel()
+	<-s.done
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shutdown
+}
+
+// ID returns the session identifier
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Context returns a context that is cancelled when the session ends
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Done returns a channel that is closed once the session's reader has exited
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Backend returns the process or stream behind the session
+func (s *Session) Backend() Backend {
+	return s.backend
+}
+
+// Scrollback returns the session's output history
+func (s *Session) Scrollback() *Scrollback {
+	return s.scrollback
+}
+
+// Screen returns the session's emulated screen
+func (s *Session) Screen() *Screen {
+	return s.screen
+}
+
+// Commands returns the session's command history
+func (s *Session) Commands() *CommandTracker {
+	return s.commands
+}
+
+// Ack acknowledges every output frame up to and including seq
+func (s *Session) Ack(seq uint64) {
+	s.pump.ack(seq)
+}
+
+// ResetFlow discards outstanding acknowledgements and replays the retained
+// output, for a frontend that has just subscribed. Output sent before it
+// subscribed, such as a restored prelude or a reattached daemon session's
+// history, would otherwise never be shown.
+func (s *Session) ResetFlow() {
+	s.pump.reset()
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if s.outDone {
+		return
+	}
+	s.pump.replay(s.scrollback.Raw())
+}
+
+// SetEncoding changes how subsequent output frames are encoded
+func (s *Session) SetEncoding(enc OutputEncoding) {
+	s.pump.setEncoding(enc)
+}
+
+// StartRecording records the session to an asciicast v2 file at path.
+// Keystrokes are only recorded when recordInput is true.
+func (s *Session) StartRecording(path string, recordInput bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exited {
+		return fmt.Errorf("session %s has exited", s.id)
+	}
+	if s.recorder != nil {
+		return fmt.Errorf("session %s is already being recorded", s.id)
+	}
+
+	rows, cols := s.screen.Size()
+	title := s.screen.Snapshot(false).Title
+	rec, err := NewRecorder(path, rows, cols, title, s.backend.GetShell(), recordInput)
+	if err != nil {
+		return err
+	}
+	s.recorder = rec
+	return nil
+}
+
+// StopRecording finishes the current recording and returns its path
+func (s *Session) StopRecording() (string, error) {
+	s.mu.Lock()
+	rec := s.recorder
+	s.recorder = nil
+	s.mu.Unlock()
+
+	if rec == nil {
+		return "", fmt.Errorf("session %s is not being recorded", s.id)
+	}
+	return rec.Path(), rec.Close()
+}
+
+// activeRecorder returns the current recorder, if any
+func (s *Session) activeRecorder() *Recorder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.recorder
+}
+
+// Write sends input to the session
+func (s *Session) Write(data []byte) (int, error) {
+	if rec := s.activeRecorder(); rec != nil {
+		rec.Input(data)
+	}
+	return s.backend.Write(data)
+}
+
+// Resize updates the session's terminal size
+func (s *Session) Resize(rows, cols int) error {
+	if err := s.backend.Resize(rows, cols); err != nil {
+		return err
+	}
+	s.screen.Resize(rows, cols)
+	if rec := s.activeRecorder(); rec != nil {
+		rec.Resize(rows, cols)
+	}
+	return nil
+}
+
+// GetShell returns the shell running in the session
+func (s *Session) GetShell() string {
+	return s.backend.GetShell()
+}
+
+// Info returns a description of the session
+func (s *Session) Info() SessionInfo {
+	info := SessionInfo{
+		ID:        s.id,
+		Shell:     s.backend.GetShell(),
+		Kind:      s.backend.Kind(),
+		Profile:   s.profile,
+		CreatedAt: s.createdAt,
+		Running:   true,
+	}
+
+	s.mu.RLock()
+	exited, status := s.exited, s.status
+	s.mu.RUnlock()
+	if exited {
+		info.Running = false
+		info.Exit = &status
+	} else if fg, err := s.Foreground(); err == nil {
+		info.Foreground = &fg
+	}
+	return info
+}
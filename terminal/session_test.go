@@ -0,0 +1,117 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipeBackend is a Backend whose output the test writes
+type pipeBackend struct {
+	out       *io.PipeReader
+	in        *io.PipeWriter
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeBackend() *pipeBackend {
+	r, w := io.Pipe()
+	return &pipeBackend{out: r, in: w, done: make(chan struct{})}
+}
+
+func (b *pipeBackend) Read(p []byte) (int, error)  { return b.out.Read(p) }
+func (b *pipeBackend) Write(p []byte) (int, error) { return len(p), nil }
+func (b *pipeBackend) Resize(rows, cols int) error { return nil }
+func (b *pipeBackend) GetShell() string            { return "pipe" }
+func (b *pipeBackend) Kind() string                { return "pipe" }
+
+func (b *pipeBackend) Close() error {
+	b.closeOnce.Do(func() {
+		b.out.Close()
+		close(b.done)
+	})
+	return nil
+}
+
+func (b *pipeBackend) Wait() (ExitStatus, error) {
+	<-b.done
+	return ExitStatus{}, nil
+}
+
+// frameRecorder collects the frames a session emits
+type frameRecorder struct {
+	mu     sync.Mutex
+	frames []Frame
+}
+
+func (r *frameRecorder) hooks() Hooks {
+	return Hooks{Output: func(id string, frame Frame) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.frames = append(r.frames, frame)
+	}}
+}
+
+// waitFor waits until a frame satisfies match and returns it
+func (r *frameRecorder) waitFor(t *testing.T, what string, match func(Frame) bool) Frame {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		for _, frame := range r.frames {
+			if match(frame) {
+				r.mu.Unlock()
+				return frame
+			}
+		}
+		r.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no frame with %s", what)
+	return Frame{}
+}
+
+func TestResetFlowReplaysOutput(t *testing.T) {
+	var rec frameRecorder
+	m := NewSessionManager(context.Background(), rec.hooks())
+	defer m.CloseAll()
+
+	backend := newPipeBackend()
+	session := m.Adopt(backend)
+
+	// Output sent before the frontend subscribes is lost to it
+	io.WriteString(backend.in, "before\r\n")
+	rec.waitFor(t, "early output", func(f Frame) bool { return strings.Contains(f.Data, "before") })
+
+	session.ResetFlow()
+	replay := rec.waitFor(t, "a replay", func(f Frame) bool { return f.Replay })
+	if replay.Data != "before\r\n" {
+		t.Errorf("replay = %q, want the output so far", replay.Data)
+	}
+
+	io.WriteString(backend.in, "after")
+	live := rec.waitFor(t, "live output", func(f Frame) bool { return f.Data == "after" })
+	if live.Replay || live.Seq <= replay.Seq {
+		t.Errorf("live frame %+v does not follow the replay %+v", live, replay)
+	}
+}
+
+func TestResetFlowReplaysPrelude(t *testing.T) {
+	var rec frameRecorder
+	m := NewSessionManager(context.Background(), rec.hooks())
+	defer m.CloseAll()
+
+	backend := newPipeBackend()
+	session := m.adoptLaunched(backend, LaunchOptions{}, []byte("restored\r\n"))
+	io.WriteString(backend.in, "$ ")
+	rec.waitFor(t, "the prompt", func(f Frame) bool { return strings.Contains(f.Data, "$ ") })
+
+	session.ResetFlow()
+	replay := rec.waitFor(t, "a replay", func(f Frame) bool { return f.Replay })
+	if replay.Data != "restored\r\n$ " {
+		t.Errorf("replay = %q, want the prelude followed by the shell's output", replay.Data)
+	}
+}
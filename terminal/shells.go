@@ -0,0 +1,173 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dialects group shells by the command syntax they accept
+const (
+	DialectPOSIX      = "posix"
+	DialectBash       = "bash"
+	DialectZsh        = "zsh"
+	DialectFish       = "fish"
+	DialectNushell    = "nushell"
+	DialectElvish     = "elvish"
+	DialectXonsh      = "xonsh"
+	DialectCsh        = "csh"
+	DialectPowerShell = "powershell"
+	DialectCmd        = "cmd"
+)
+
+// versionProbeTimeout bounds how long a shell may take to print its version
+const versionProbeTimeout = 2 * time.Second
+
+// ShellInfo represents a detected shell
+type ShellInfo struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Version is empty when the shell cannot report one
+	Version string `json:"version,omitempty"`
+	// Dialect is the command syntax the shell accepts, e.g. "posix" or "fish"
+	Dialect string `json:"dialect"`
+}
+
+// shellKind describes a known shell executable
+type shellKind struct {
+	name        string
+	description string
+	dialect     string
+	// versionArgs make the shell print its version; nil if it has no such flag
+	versionArgs []string
+}
+
+// knownShells maps executable names to what they are
+var knownShells = map[string]shellKind{
+	"sh":         {"Sh", "Bourne Shell", DialectPOSIX, nil},
+	"bash":       {"Bash", "Bourne Again Shell", DialectBash, []string{"--version"}},
+	"rbash":      {"Restricted Bash", "Bourne Again Shell in restricted mode", DialectBash, []string{"--version"}},
+	"zsh":        {"Zsh", "Z shell", DialectZsh, []string{"--version"}},
+	"fish":       {"Fish", "Friendly Interactive Shell", DialectFish, []string{"--version"}},
+	"nu":         {"Nushell", "Structured data shell", DialectNushell, []string{"--version"}},
+	"elvish":     {"Elvish", "Expressive programming shell", DialectElvish, []string{"-version"}},
+	"xonsh":      {"Xonsh", "Python-powered shell", DialectXonsh, []string{"--version"}},
+	"ksh":        {"Ksh", "KornShell", DialectPOSIX, []string{"--version"}},
+	"ksh93":      {"Ksh", "KornShell", DialectPOSIX, []string{"--version"}},
+	"mksh":       {"Mksh", "MirBSD Korn Shell", DialectPOSIX, nil},
+	"dash":       {"Dash", "Debian Almquist Shell", DialectPOSIX, nil},
+	"ash":        {"Ash", "Almquist Shell", DialectPOSIX, nil},
+	"tcsh":       {"Tcsh", "TENEX C shell", DialectCsh, []string{"--version"}},
+	"csh":        {"Csh", "C shell", DialectCsh, nil},
+	"pwsh":       {"PowerShell 7", "Cross-platform PowerShell", DialectPowerShell, []string{"--version"}},
+	"powershell": {"Windows PowerShell", "Built-in Windows PowerShell", DialectPowerShell, nil},
+	"cmd":        {"Command Prompt", "Classic Windows CMD", DialectCmd, nil},
+	"wsl":        {"WSL", "Windows Subsystem for Linux", DialectBash, nil},
+}
+
+// shellType returns the lower-case executable name of a shell path
+func shellType(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	return strings.TrimSuffix(name, ".exe")
+}
+
+// ShellDialect returns the command syntax accepted by the shell at path.
+// Unknown shells are assumed to be POSIX compatible.
+func ShellDialect(path string) string {
+	if kind, ok := knownShells[shellType(path)]; ok {
+		return kind.dialect
+	}
+	return DialectPOSIX
+}
+
+// newShellInfo describes the shell at path without probing its version
+func newShellInfo(path string) ShellInfo {
+	typ := shellType(path)
+	info := ShellInfo{Name: typ, Path: path, Type: typ, Dialect: DialectPOSIX}
+	if kind, ok := knownShells[typ]; ok {
+		info.Name = kind.name
+		info.Description = kind.description
+		info.Dialect = kind.dialect
+	}
+	return info
+}
+
+// versionPattern finds a dotted version number in a shell's version output
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+[\w.+-]*`)
+
+// versionCache remembers probed versions by path, invalidated when the
+// executable changes
+var versionCache = struct {
+	sync.Mutex
+	entries map[string]cachedVersion
+}{entries: make(map[string]cachedVersion)}
+
+type cachedVersion struct {
+	modTime time.Time
+	version string
+}
+
+// probeVersions fills in the version of every shell, running the probes in
+// parallel
+func probeVersions(shells []ShellInfo) {
+	var wg sync.WaitGroup
+	for i := range shells {
+		wg.Add(1)
+		go func(info *ShellInfo) {
+			defer wg.Done()
+			info.Version = shellVersion(info.Path)
+		}(&shells[i])
+	}
+	wg.Wait()
+}
+
+// shellVersion asks the shell at path for its version
+func shellVersion(path string) string {
+	kind, ok := knownShells[shellType(path)]
+	if !ok || kind.versionArgs == nil {
+		return ""
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	versionCache.Lock()
+	cached, ok := versionCache.entries[path]
+	versionCache.Unlock()
+	if ok && cached.modTime.Equal(stat.ModTime()) {
+		return cached.version
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
+	defer cancel()
+	// Some shells, such as ksh93, print their version on stderr
+	out, _ := exec.CommandContext(ctx, path, kind.versionArgs...).CombinedOutput()
+	version := parseVersion(out)
+
+	versionCache.Lock()
+	versionCache.entries[path] = cachedVersion{modTime: stat.ModTime(), version: version}
+	versionCache.Unlock()
+	return version
+}
+
+// parseVersion extracts the version number from the first line of output
+// that has one
+func parseVersion(out []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if version := versionPattern.FindString(scanner.Text()); version != "" {
+			return version
+		}
+	}
+	return ""
+}
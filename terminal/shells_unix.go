@@ -0,0 +1,162 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// etcShells is the system's list of valid login shells
+const etcShells = "/etc/shells"
+
+// pathShells are looked up on PATH in addition to those in /etc/shells,
+// since package managers such as Homebrew and cargo do not register them
+var pathShells = []string{
+	"zsh", "bash", "fish", "nu", "elvish", "xonsh",
+	"ksh", "mksh", "dash", "tcsh", "csh", "pwsh", "sh",
+}
+
+// nonInteractiveShells appear in /etc/shells but are not shells to run
+var nonInteractiveShells = map[string]bool{
+	"nologin": true, "false": true, "git-shell": true,
+	"screen": true, "tmux": true, "scponly": true,
+}
+
+// GetAvailableShells returns all installed shells on the system (Unix
+// version), from /etc/shells and PATH, with their versions
+func GetAvailableShells() []ShellInfo {
+	var shells []ShellInfo
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		if nonInteractiveShells[shellType(path)] || !isExecutable(path) {
+			return
+		}
+		// /bin and /usr/bin are often the same directory
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			real = path
+		}
+		if seen[real] || seen[path] {
+			return
+		}
+		seen[real], seen[path] = true, true
+		shells = append(shells, newShellInfo(path))
+	}
+
+	registered, _ := readEtcShells()
+	for _, path := range registered {
+		add(path)
+	}
+	for _, name := range pathShells {
+		if path, err := exec.LookPath(name); err == nil {
+			add(path)
+		}
+	}
+
+	probeVersions(shells)
+	return shells
+}
+
+// readEtcShells returns the shell paths listed in /etc/shells
+func readEtcShells() ([]string, error) {
+	f, err := os.Open(etcShells)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "/") {
+			paths = append(paths, line)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// isExecutable reports whether path is an executable regular file
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0
+}
+
+// validateShell checks that path is an executable shell the system
+// accepts, as chsh does, rather than trusting $SHELL blindly
+func validateShell(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("shell %s is not an absolute path", path)
+	}
+	if !isExecutable(path) {
+		return fmt.Errorf("shell %s is not executable", path)
+	}
+	if nonInteractiveShells[shellType(path)] {
+		return fmt.Errorf("shell %s does not accept logins", path)
+	}
+	registered, err := readEtcShells()
+	if err != nil || len(registered) == 0 {
+		// Without a list there is nothing more to check
+		return nil
+	}
+	real, _ := filepath.EvalSymlinks(path)
+	for _, candidate := range registered {
+		if candidate == path {
+			return nil
+		}
+		if resolved, err := filepath.EvalSymlinks(candidate); err == nil && resolved == real {
+			return nil
+		}
+	}
+	return fmt.Errorf("shell %s is not listed in %s", path, etcShells)
+}
+
+// loginShell returns the current user's shell from /etc/passwd
+func loginShell() (string, error) {
+	f, err := os.Open("/etc/passwd")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	uid := strconv.Itoa(os.Getuid())
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) == 7 && fields[2] == uid && fields[6] != "" {
+			return fields[6], nil
+		}
+	}
+	return "", fmt.Errorf("no login shell for uid %s", uid)
+}
+
+// detectShell determines the user's shell: $SHELL if it is a valid shell,
+// then the login shell from /etc/passwd, then common shells
+func detectShell() (string, error) {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		if err := validateShell(shell); err == nil {
+			return shell, nil
+		} else {
+			fmt.Printf("Ignoring $SHELL: %v\n", err)
+		}
+	}
+	if shell, err := loginShell(); err == nil && validateShell(shell) == nil {
+		return shell, nil
+	}
+	for _, sh := range []string{"/bin/zsh", "/bin/bash", "/bin/sh"} {
+		if isExecutable(sh) {
+			return sh, nil
+		}
+	}
+	return "", fmt.Errorf("no shell found")
+}
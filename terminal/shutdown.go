@@ -0,0 +1,29 @@
+package terminal
+
+import "time"
+
+const (
+	// shutdownTimeout is how long a session's processes get to exit after
+	// the terminal hangs up before they are killed
+	shutdownTimeout = 2 * time.Second
+	// killTimeout is how long to wait for killed processes to disappear
+	killTimeout = 500 * time.Millisecond
+)
+
+// ShutdownReport describes how a session's processes ended
+type ShutdownReport struct {
+	SessionID string `json:"session_id"`
+	// Forced is true when processes ignored the hangup and had to be killed
+	Forced bool `json:"forced"`
+	// Survivors are processes still running after the shutdown, e.g. ones
+	// that could not be killed or were not ours to kill
+	Survivors []ForegroundProcess `json:"survivors,omitempty"`
+}
+
+// shutdowner is implemented by backends that can end the processes they
+// started, not just their own connection to them
+type shutdowner interface {
+	// Shutdown ends every process of the backend, waiting up to timeout
+	// before escalating. It is safe to call more than once.
+	Shutdown(timeout time.Duration) ShutdownReport
+}
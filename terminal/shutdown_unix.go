@@ -0,0 +1,103 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"syscall"
+	"time"
+)
+
+// shutdownPollInterval is how often shutdown checks for remaining processes
+const shutdownPollInterval = 50 * time.Millisecond
+
+// Shutdown hangs up the terminal, gives the shell and its jobs until timeout
+// to exit, then kills every process group left in the session
+func (s *PTYSession) Shutdown(timeout time.Duration) ShutdownReport {
+	s.shutdownOnce.Do(func() {
+		s.shutdownReport = s.shutdown(timeout)
+	})
+	return s.shutdownReport
+}
+
+// shutdown runs the hangup sequence once
+func (s *PTYSession) shutdown(timeout time.Duration) ShutdownReport {
+	sid := s.cmd.Process.Pid
+	// The foreground group can only be read while the master is open
+	groups := s.processGroups()
+	signalAll := func(sig syscall.Signal) {
+		for _, pgrp := range groups {
+			syscall.Kill(-pgrp, sig)
+		}
+	}
+
+	// Stopped jobs only act on the hangup once they are resumed
+	signalAll(syscall.SIGHUP)
+	signalAll(syscall.SIGCONT)
+	s.PTY.Close()
+
+	exited := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(exited)
+	}()
+
+	var report ShutdownReport
+	if !waitForExit(exited, sid, groups, timeout) {
+		report.Forced = true
+		signalAll(syscall.SIGKILL)
+		waitForExit(exited, sid, groups, killTimeout)
+	}
+
+	select {
+	case <-exited:
+		for _, pid := range liveProcesses(sid, groups) {
+			proc, _ := readProcess(pid)
+			report.Survivors = append(report.Survivors, proc)
+		}
+	default:
+		// Not even the shell could be killed, e.g. it is stuck in the kernel
+		proc, _ := readProcess(sid)
+		report.Survivors = append(report.Survivors, proc)
+	}
+	return report
+}
+
+// waitForExit waits until the shell has been reaped and no other process
+// remains in its session, or until timeout. It reports whether all exited.
+func waitForExit(exited <-chan struct{}, sid int, groups []int, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-exited:
+			if len(liveProcesses(sid, groups)) == 0 {
+				return true
+			}
+		default:
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return false
+		}
+	}
+}
+
+// liveProcesses returns the processes left in session sid. Where the OS
+// cannot list session members, the known process groups are probed instead
+// and their leaders stand in for them.
+func liveProcesses(sid int, groups []int) []int {
+	if canListSessions {
+		return sessionMembers(sid)
+	}
+	var pids []int
+	for _, pgrp := range groups {
+		if syscall.Kill(-pgrp, 0) == nil {
+			pids = append(pids, pgrp)
+		}
+	}
+	return pids
+}
@@ -0,0 +1,40 @@
+package terminal
+
+import "fmt"
+
+// SignalTarget selects which processes of a session receive a signal
+type SignalTarget string
+
+const (
+	// SignalForeground targets the process group that owns the terminal
+	SignalForeground SignalTarget = "foreground"
+	// SignalSession targets every process in the terminal's session,
+	// including the shell and its background jobs
+	SignalSession SignalTarget = "session"
+)
+
+// ParseSignalTarget validates a signal target name from the frontend
+func ParseSignalTarget(name string) (SignalTarget, error) {
+	switch target := SignalTarget(name); target {
+	case SignalForeground, SignalSession:
+		return target, nil
+	default:
+		return "", fmt.Errorf("unsupported signal target: %s", name)
+	}
+}
+
+// signaler is implemented by backends whose processes can be signalled
+// directly, bypassing the terminal's line discipline
+type signaler interface {
+	Signal(name string, target SignalTarget) error
+}
+
+// Signal sends a signal such as "SIGINT" or "KILL" to the session's
+// foreground job or to all of its processes
+func (s *Session) Signal(name string, target SignalTarget) error {
+	sig, ok := s.backend.(signaler)
+	if !ok {
+		return fmt.Errorf("session %s does not support signals", s.id)
+	}
+	return sig.Signal(name, target)
+}
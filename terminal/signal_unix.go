@@ -0,0 +1,91 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// signals are the signals the frontend may deliver
+var signals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTSTP": syscall.SIGTSTP,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGCONT": syscall.SIGCONT,
+}
+
+// parseSignal accepts a signal name with or without the SIG prefix
+func parseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := signals[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported signal: %s", name)
+	}
+	return sig, nil
+}
+
+// Signal delivers a signal to the terminal's foreground process group or to
+// every process group in the shell's session
+func (s *PTYSession) Signal(name string, target SignalTarget) error {
+	sig, err := parseSignal(name)
+	if err != nil {
+		return err
+	}
+
+	var groups []int
+	switch target {
+	case SignalForeground:
+		pgrp, err := foregroundPgrp(s.PTY)
+		if err != nil {
+			return fmt.Errorf("failed to get foreground process group: %w", err)
+		}
+		groups = []int{pgrp}
+	case SignalSession:
+		groups = s.processGroups()
+	default:
+		return fmt.Errorf("unsupported signal target: %s", target)
+	}
+
+	for _, pgrp := range groups {
+		if err := syscall.Kill(-pgrp, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("failed to send %s to process group %d: %w", name, pgrp, err)
+		}
+	}
+	return nil
+}
+
+// processGroups returns the process groups in the shell's session. The shell
+// leads its own session, so its pid is also the session and group ID.
+func (s *PTYSession) processGroups() []int {
+	sid := s.cmd.Process.Pid
+	groups := []int{sid}
+	if pgrp, err := foregroundPgrp(s.PTY); err == nil && pgrp > 0 && pgrp != sid {
+		groups = append(groups, pgrp)
+	}
+	// Background jobs are only visible where the OS lists session members
+	for _, pid := range sessionMembers(sid) {
+		if pgrp, err := syscall.Getpgid(pid); err == nil && !containsInt(groups, pgrp) {
+			groups = append(groups, pgrp)
+		}
+	}
+	return groups
+}
+
+// containsInt reports whether v is in list
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
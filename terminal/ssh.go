@@ -0,0 +1,541 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// sshDialTimeout bounds connecting and authenticating to a host
+	sshDialTimeout = 15 * time.Second
+	// sshTerm is the terminal type requested for the remote PTY
+	sshTerm = "xterm-256color"
+)
+
+// defaultIdentityFiles are tried when no identity file is configured, as ssh does
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// SSHConfig describes how to open a shell on a remote host
+type SSHConfig struct {
+	Host string `json:"host"`
+	// Port defaults to 22
+	Port int `json:"port,omitempty"`
+	// User defaults to the local user name
+	User string `json:"user,omitempty"`
+	// IdentityFiles are private keys to offer; empty tries the usual keys in ~/.ssh
+	IdentityFiles []string `json:"identity_files,omitempty"`
+	// Password is offered after keys, for password and keyboard-interactive auth
+	Password string `json:"password,omitempty"`
+	// UseAgent offers the keys held by the agent at $SSH_AUTH_SOCK
+	UseAgent bool `json:"use_agent"`
+	// KnownHostsFile defaults to ~/.ssh/known_hosts
+	KnownHostsFile string `json:"known_hosts_file,omitempty"`
+	// AcceptNewHostKey records the key of a host not yet in known_hosts
+	// instead of refusing it, like StrictHostKeyChecking=accept-new.
+	// A changed key is always refused.
+	AcceptNewHostKey bool `json:"accept_new_host_key"`
+	// ProxyJump lists jump hosts as comma-separated [user@]host[:port],
+	// connected through in order, like ssh -J
+	ProxyJump string `json:"proxy_jump,omitempty"`
+	// Jumps are jump hosts with settings of their own, as resolved from
+	// ~/.ssh/config, connected through in order. They take the place of ProxyJump.
+	Jumps []SSHConfig `json:"jumps,omitempty"`
+	Rows  int         `json:"rows,omitempty"`
+	Cols  int         `json:"cols,omitempty"`
+
+	// HostKeyCallback replaces known_hosts verification, e.g. to pin the
+	// key of an in-process test server
+	HostKeyCallback ssh.HostKeyCallback `json:"-"`
+	// Dial replaces the TCP connection to the host, e.g. to go through a jump host
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error) `json:"-"`
+}
+
+// address returns host:port for the configured host
+func (c SSHConfig) address() string {
+	port := c.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// SSHSession is a Backend running a login shell on a remote host
+type SSHSession struct {
+	client  *ssh.Client
+	session *ssh.Session
+	stdin   io.WriteCloser
+	output  *io.PipeReader
+	target  string
+	rows    int
+	cols    int
+	// jumps are the connections to jump hosts, innermost last
+	jumps []*ssh.Client
+
+	waitOnce   sync.Once
+	exitStatus ExitStatus
+	waitErr    error
+	closeOnce  sync.Once
+}
+
+// NewSSHSession connects to a host, requests a PTY and starts the user's
+// login shell
+func NewSSHSession(ctx context.Context, cfg SSHConfig) (*SSHSession, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("ssh host is required")
+	}
+	if cfg.User == "" {
+		cfg.User = localUser()
+	}
+	if cfg.Rows <= 0 || cfg.Cols <= 0 {
+		cfg.Rows, cfg.Cols = defaultRows, defaultCols
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sshDialTimeout)
+	defer cancel()
+
+	dial := cfg.Dial
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+
+	// Each jump host is reached through the one before it
+	var jumps []*ssh.Client
+	closeJumps := func() {
+		for i := len(jumps) - 1; i >= 0; i-- {
+			jumps[i].Close()
+		}
+	}
+	for _, hop := range cfg.jumpHosts() {
+		jump, err := sshConnect(ctx, dial, hop)
+		if err != nil {
+			closeJumps()
+			return nil, fmt.Errorf("jump host %s: %w", formatJumpHost(hop.User, hop.Host, hop.Port), err)
+		}
+		jumps = append(jumps, jump)
+		dial = jumpDialer(jump)
+	}
+
+	client, err := sshConnect(ctx, dial, cfg)
+	if err != nil {
+		closeJumps()
+		return nil, err
+	}
+
+	s, err := startSSHShell(client, cfg)
+	if err != nil {
+		client.Close()
+		closeJumps()
+		return nil, err
+	}
+	s.target = cfg.User + "@" + cfg.Host
+	s.rows, s.cols = cfg.Rows, cfg.Cols
+	s.jumps = jumps
+	return s, nil
+}
+
+// jumpHosts returns the hosts to connect through. Hosts listed in
+// ProxyJump have no settings of their own and use the target's.
+func (c SSHConfig) jumpHosts() []SSHConfig {
+	if len(c.Jumps) > 0 || c.ProxyJump == "" {
+		return c.Jumps
+	}
+	var hops []SSHConfig
+	for _, spec := range strings.Split(c.ProxyJump, ",") {
+		user, host, port := parseJumpHost(strings.TrimSpace(spec))
+		hop := c
+		hop.Host, hop.Port, hop.User = host, port, user
+		hop.ProxyJump, hop.Jumps, hop.Dial = "", nil, nil
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// sshConnect dials a host and completes the SSH handshake, authenticating
+// and checking the host key with the host's own settings
+func sshConnect(ctx context.Context, dial func(ctx context.Context, network, addr string) (net.Conn, error), cfg SSHConfig) (*ssh.Client, error) {
+	if cfg.User == "" {
+		cfg.User = localUser()
+	}
+
+	hostKeyCallback := cfg.HostKeyCallback
+	if hostKeyCallback == nil {
+		callback, err := knownHostsCallback(cfg.KnownHostsFile, cfg.AcceptNewHostKey)
+		if err != nil {
+			return nil, err
+		}
+		hostKeyCallback = callback
+	}
+
+	auth, closeAgent := sshAuthMethods(cfg)
+	defer closeAgent()
+	if len(auth) == 0 {
+		return nil, errors.New("no ssh authentication methods available")
+	}
+
+	addr := cfg.address()
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	// The handshake has no context of its own
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// jumpDialer opens TCP connections through an established SSH connection
+func jumpDialer(client *ssh.Client) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return client.DialContext(ctx, network, addr)
+	}
+}
+
+// parseJumpHost splits a [user@]host[:port] jump host specification
+func parseJumpHost(spec string) (user, host string, port int) {
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		user, spec = spec[:i], spec[i+1:]
+	}
+	host = spec
+	if h, p, err := net.SplitHostPort(spec); err == nil {
+		if n, err := strconv.Atoi(p); err == nil {
+			host, port = h, n
+		}
+	}
+	return user, strings.Trim(host, "[]"), port
+}
+
+// formatJumpHost is the inverse of parseJumpHost
+func formatJumpHost(user, host string, port int) string {
+	spec := host
+	if port != 0 {
+		spec = net.JoinHostPort(host, strconv.Itoa(port))
+	} else if strings.Contains(host, ":") {
+		spec = "[" + host + "]"
+	}
+	if user != "" {
+		spec = user + "@" + spec
+	}
+	return spec
+}
+
+// startSSHShell opens a session channel with a PTY and starts the shell
+func startSSHShell(client *ssh.Client, cfg SSHConfig) (*SSHSession, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ssh session: %w", err)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 38400,
+		ssh.TTY_OP_OSPEED: 38400,
+	}
+	if err := session.RequestPty(sshTerm, cfg.Rows, cfg.Cols, modes); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to request remote pty: %w", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	// The remote PTY merges stderr into stdout, but servers may still use
+	// the extended channel, so both feed the session's single reader
+	pr, pw := io.Pipe()
+	session.Stdout = pw
+	session.Stderr = pw
+
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to start remote shell: %w", err)
+	}
+
+	s := &SSHSession{
+		client:  client,
+		session: session,
+		stdin:   stdin,
+		output:  pr,
+	}
+	go func() {
+		s.Wait()
+		pw.Close()
+	}()
+	return s, nil
+}
+
+// sshAuthMethods collects the configured authentication methods in the
+// order ssh tries them: agent, keys, then password. The returned function
+// releases the agent connection once authentication is over.
+func sshAuthMethods(cfg SSHConfig) ([]ssh.AuthMethod, func()) {
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+
+	if cfg.UseAgent {
+		if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+			if conn, err := net.Dial("unix", sock); err == nil {
+				methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+				closeAgent = func() { conn.Close() }
+			}
+		}
+	}
+
+	if signers := identitySigners(cfg.IdentityFiles); len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if cfg.Password != "" {
+		password := cfg.Password
+		methods = append(methods,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+	return methods, closeAgent
+}
+
+// identitySigners loads private keys. Keys that are missing or need a
+// passphrase are skipped, as the agent may hold them instead.
+func identitySigners(files []string) []ssh.Signer {
+	explicit := len(files) > 0
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range defaultIdentityFiles {
+				files = append(files, filepath.Join(home, ".ssh", name))
+			}
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, file := range files {
+		path, err := expandHome(file)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if explicit {
+				fmt.Printf("Skipping ssh identity %s: %v\n", file, err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			fmt.Printf("Skipping ssh identity %s: %v\n", file, err)
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
+
+// knownHostsCallback verifies host keys against a known_hosts file
+func knownHostsCallback(file string, acceptNew bool) (ssh.HostKeyCallback, error) {
+	if file == "" {
+		file = "~/.ssh/known_hosts"
+	}
+	path, err := expandHome(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if acceptNew {
+		// knownhosts needs the file to exist before it can be appended to
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create known_hosts: %w", err)
+		}
+		f.Close()
+	}
+
+	verify, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := verify(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		fingerprint := ssh.FingerprintSHA256(key)
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("host key for %s has changed (now %s); refusing to connect", hostname, fingerprint)
+		}
+		if !acceptNew {
+			return fmt.Errorf("host %s is not in known_hosts (key %s)", hostname, fingerprint)
+		}
+		return appendKnownHost(path, hostname, remote, key)
+	}, nil
+}
+
+// appendKnownHost records a newly accepted host key
+func appendKnownHost(path, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil {
+		if addr := knownhosts.Normalize(remote.String()); addr != addresses[0] {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to update known_hosts: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, knownhosts.Line(addresses, key)); err != nil {
+		return fmt.Errorf("failed to update known_hosts: %w", err)
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// localUser returns the name of the user running the app
+func localUser() string {
+	for _, key := range []string{"USER", "USERNAME", "LOGNAME"} {
+		if name := os.Getenv(key); name != "" {
+			return name
+		}
+	}
+	return "root"
+}
+
+// Write sends input to the remote shell
+func (s *SSHSession) Write(data []byte) (int, error) {
+	return s.stdin.Write(data)
+}
+
+// Read reads output from the remote shell
+func (s *SSHSession) Read(p []byte) (int, error) {
+	return s.output.Read(p)
+}
+
+// Resize sends a window-change request for the remote PTY
+func (s *SSHSession) Resize(rows, cols int) error {
+	return s.session.WindowChange(rows, cols)
+}
+
+// Size returns the terminal size requested when the session started
+func (s *SSHSession) Size() (rows, cols int) {
+	return s.rows, s.cols
+}
+
+// Close ends the remote session and the connection, which hangs up the
+// remote shell
+func (s *SSHSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.session.Close()
+		err = s.client.Close()
+		for i := len(s.jumps) - 1; i >= 0; i-- {
+			s.jumps[i].Close()
+		}
+		s.output.CloseWithError(io.EOF)
+	})
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// Wait blocks until the remote shell exits or the connection drops. It is
+// safe to call more than once.
+func (s *SSHSession) Wait() (ExitStatus, error) {
+	s.waitOnce.Do(func() {
+		err := s.session.Wait()
+		var exitErr *ssh.ExitError
+		var missingErr *ssh.ExitMissingError
+		switch {
+		case err == nil:
+		case errors.As(err, &exitErr):
+			s.exitStatus = ExitStatus{Code: exitErr.ExitStatus(), Signal: exitErr.Signal()}
+		case errors.As(err, &missingErr):
+			// The connection closed without the shell reporting a status
+			s.exitStatus = ExitStatus{Code: -1}
+		default:
+			s.exitStatus = ExitStatus{Code: -1}
+			s.waitErr = err
+		}
+	})
+	return s.exitStatus, s.waitErr
+}
+
+// sshSignals are the signals defined for SSH sessions by RFC 4254
+var sshSignals = map[string]ssh.Signal{
+	"SIGABRT": ssh.SIGABRT, "SIGALRM": ssh.SIGALRM, "SIGFPE": ssh.SIGFPE,
+	"SIGHUP": ssh.SIGHUP, "SIGILL": ssh.SIGILL, "SIGINT": ssh.SIGINT,
+	"SIGKILL": ssh.SIGKILL, "SIGPIPE": ssh.SIGPIPE, "SIGQUIT": ssh.SIGQUIT,
+	"SIGSEGV": ssh.SIGSEGV, "SIGTERM": ssh.SIGTERM,
+	"SIGUSR1": ssh.SIGUSR1, "SIGUSR2": ssh.SIGUSR2,
+}
+
+// Signal asks the server to signal the remote shell. The protocol has no
+// notion of process groups, so both targets reach the same process, and
+// servers may ignore the request altogether.
+func (s *SSHSession) Signal(name string, target SignalTarget) error {
+	upper := strings.ToUpper(name)
+	if !strings.HasPrefix(upper, "SIG") {
+		upper = "SIG" + upper
+	}
+	sig, ok := sshSignals[upper]
+	if !ok {
+		return fmt.Errorf("unsupported signal over ssh: %s", name)
+	}
+	return s.session.Signal(sig)
+}
+
+// GetShell describes the remote end as user@host
+func (s *SSHSession) GetShell() string {
+	return s.target
+}
+
+// Kind identifies SSHSession as the remote shell backend
+func (s *SSHSession) Kind() string {
+	return "ssh"
+}
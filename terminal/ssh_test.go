@@ -0,0 +1,419 @@
+package terminal
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is an in-process SSH server whose "shell" reports the PTY
+// and window changes it is sent, and exits with status 3 on q
+type testSSHServer struct {
+	addr     string
+	hostKey  ssh.PublicKey
+	password string
+	userKey  ssh.PublicKey
+	authed   chan string
+}
+
+func newTestSSHServer(t *testing.T, password string, userKey ssh.PublicKey) *testSSHServer {
+	t.Helper()
+	hostSigner := newTestSigner(t)
+
+	srv := &testSSHServer{
+		hostKey:  hostSigner.PublicKey(),
+		password: password,
+		userKey:  userKey,
+		authed:   make(chan string, 1),
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if srv.password != "" && string(pass) == srv.password {
+				srv.recordAuth("password")
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if srv.userKey != nil && string(key.Marshal()) == string(srv.userKey.Marshal()) {
+				srv.recordAuth("publickey")
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	srv.addr = ln.Addr().String()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn, config)
+		}
+	}()
+	return srv
+}
+
+// recordAuth notes how a client authenticated, keeping the first method
+// seen until the test reads it
+func (srv *testSSHServer) recordAuth(method string) {
+	select {
+	case srv.authed <- method:
+	default:
+	}
+}
+
+func (srv *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() == "direct-tcpip" {
+			go srv.forward(newCh)
+			continue
+		}
+		if newCh.ChannelType() != "session" {
+			newCh.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		ch, requests, err := newCh.Accept()
+		if err != nil {
+			return
+		}
+		go srv.session(ch, requests)
+	}
+}
+
+// forward relays a direct-tcpip channel, so the server can act as a jump host
+func (srv *testSSHServer) forward(newCh ssh.NewChannel) {
+	var target struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(newCh.ExtraData(), &target); err != nil {
+		newCh.Reject(ssh.ConnectionFailed, "bad request")
+		return
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, requests, err := newCh.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(ch, conn)
+		ch.Close()
+	}()
+	io.Copy(conn, ch)
+	conn.Close()
+}
+
+func (srv *testSSHServer) session(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			var pty struct {
+				Term          string
+				Cols, Rows    uint32
+				Width, Height uint32
+				Modes         string
+			}
+			ok := ssh.Unmarshal(req.Payload, &pty) == nil
+			req.Reply(ok, nil)
+			fmt.Fprintf(ch, "pty %s %dx%d\r\n", pty.Term, pty.Cols, pty.Rows)
+		case "window-change":
+			var size struct {
+				Cols, Rows    uint32
+				Width, Height uint32
+			}
+			if ssh.Unmarshal(req.Payload, &size) == nil {
+				fmt.Fprintf(ch, "size %dx%d\r\n", size.Cols, size.Rows)
+			}
+		case "shell":
+			req.Reply(true, nil)
+			go func() {
+				buf := make([]byte, 1)
+				for {
+					if _, err := ch.Read(buf); err != nil {
+						return
+					}
+					if buf[0] == 'q' {
+						ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{3}))
+						ch.Close()
+						return
+					}
+				}
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func (srv *testSSHServer) config() SSHConfig {
+	host, port, _ := net.SplitHostPort(srv.addr)
+	p, _ := strconv.Atoi(port)
+	return SSHConfig{
+		Host:            host,
+		Port:            p,
+		User:            "tester",
+		HostKeyCallback: ssh.FixedHostKey(srv.hostKey),
+		Rows:            24,
+		Cols:            80,
+	}
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey: %v", err)
+	}
+	return signer
+}
+
+// readUntil reads session output until it contains want
+func readUntil(t *testing.T, r io.Reader, want string) string {
+	t.Helper()
+	done := make(chan string, 1)
+	go func() {
+		var out strings.Builder
+		buf := make([]byte, 256)
+		for !strings.Contains(out.String(), want) {
+			n, err := r.Read(buf)
+			out.Write(buf[:n])
+			if err != nil {
+				break
+			}
+		}
+		done <- out.String()
+	}()
+	select {
+	case out := <-done:
+		if !strings.Contains(out, want) {
+			t.Fatalf("output %q does not contain %q", out, want)
+		}
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %q", want)
+		return ""
+	}
+}
+
+func TestSSHSessionPasswordAuth(t *testing.T) {
+	srv := newTestSSHServer(t, "secret", nil)
+	cfg := srv.config()
+	cfg.Password = "secret"
+
+	s, err := NewSSHSession(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewSSHSession: %v", err)
+	}
+	defer s.Close()
+
+	if method := <-srv.authed; method != "password" {
+		t.Errorf("authenticated with %s, want password", method)
+	}
+	readUntil(t, s, "pty xterm-256color 80x24")
+
+	if err := s.Resize(30, 100); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	readUntil(t, s, "size 100x30")
+
+	if _, err := s.Write([]byte("q")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	status, err := s.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if status.Code != 3 {
+		t.Errorf("exit code = %d, want 3", status.Code)
+	}
+}
+
+func TestSSHSessionWrongPassword(t *testing.T) {
+	srv := newTestSSHServer(t, "secret", nil)
+	cfg := srv.config()
+	cfg.Password = "guess"
+
+	if s, err := NewSSHSession(context.Background(), cfg); err == nil {
+		s.Close()
+		t.Fatal("connected with the wrong password")
+	}
+}
+
+func TestSSHSessionKeyAuth(t *testing.T) {
+	_, userKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(userKey, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(userKey)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey: %v", err)
+	}
+
+	srv := newTestSSHServer(t, "", signer.PublicKey())
+	cfg := srv.config()
+	cfg.IdentityFiles = []string{keyFile}
+
+	s, err := NewSSHSession(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewSSHSession: %v", err)
+	}
+	defer s.Close()
+
+	if method := <-srv.authed; method != "publickey" {
+		t.Errorf("authenticated with %s, want publickey", method)
+	}
+	readUntil(t, s, "pty xterm-256color 80x24")
+}
+
+func TestSSHSessionJumpHostAuth(t *testing.T) {
+	_, jumpKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(jumpKey, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "jump_key")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(jumpKey)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey: %v", err)
+	}
+
+	// The jump host only takes its key and the target only its password
+	jumpSrv := newTestSSHServer(t, "", signer.PublicKey())
+	target := newTestSSHServer(t, "secret", nil)
+
+	jump := jumpSrv.config()
+	jump.IdentityFiles = []string{keyFile}
+	cfg := target.config()
+	cfg.Password = "secret"
+	cfg.Jumps = []SSHConfig{jump}
+
+	s, err := NewSSHSession(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewSSHSession: %v", err)
+	}
+	defer s.Close()
+
+	if method := <-jumpSrv.authed; method != "publickey" {
+		t.Errorf("jump host authenticated with %s, want publickey", method)
+	}
+	if method := <-target.authed; method != "password" {
+		t.Errorf("target authenticated with %s, want password", method)
+	}
+	readUntil(t, s, "pty xterm-256color 80x24")
+}
+
+func TestSSHKnownHostsChangedKey(t *testing.T) {
+	srv := newTestSSHServer(t, "secret", nil)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(srv.addr)}, newTestSigner(t).PublicKey())
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := srv.config()
+	cfg.Password = "secret"
+	cfg.HostKeyCallback = nil
+	cfg.KnownHostsFile = knownHosts
+	cfg.AcceptNewHostKey = true
+
+	s, err := NewSSHSession(context.Background(), cfg)
+	if err == nil {
+		s.Close()
+		t.Fatal("connected to a host whose key changed")
+	}
+	if !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("error = %v, want a changed host key", err)
+	}
+}
+
+func TestSSHKnownHostsNewHost(t *testing.T) {
+	srv := newTestSSHServer(t, "secret", nil)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+
+	cfg := srv.config()
+	cfg.Password = "secret"
+	cfg.HostKeyCallback = nil
+	cfg.KnownHostsFile = knownHosts
+
+	// Unknown hosts are refused unless new keys are accepted
+	if _, err := os.Create(knownHosts); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := NewSSHSession(context.Background(), cfg); err == nil {
+		s.Close()
+		t.Fatal("connected to a host not in known_hosts")
+	}
+
+	cfg.AcceptNewHostKey = true
+	s, err := NewSSHSession(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewSSHSession: %v", err)
+	}
+	s.Close()
+
+	// The accepted key is recorded and verifies the next connection
+	cfg.AcceptNewHostKey = false
+	s, err = NewSSHSession(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("reconnect with the recorded key: %v", err)
+	}
+	s.Close()
+}
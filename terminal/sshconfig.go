@@ -0,0 +1,331 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth stops Include directives that include each other
+const maxIncludeDepth = 16
+
+// SSHProfile is a host from ~/.ssh/config, resolved into a launchable config
+type SSHProfile struct {
+	// Name is the alias from the Host line
+	Name   string    `json:"name"`
+	Config SSHConfig `json:"config"`
+}
+
+// sshConfigBlock is a Host section; options keep the first value seen
+type sshConfigBlock struct {
+	patterns []string
+	options  map[string][]string
+}
+
+// sshConfigParser reads ssh_config files, following Include directives
+type sshConfigParser struct {
+	dir    string // ~/.ssh, the base for relative includes
+	blocks []*sshConfigBlock
+	// aliases are concrete Host names, in the order they appear
+	aliases []string
+}
+
+// LoadSSHProfiles parses ~/.ssh/config and returns a profile for each
+// concrete host alias. Wildcard patterns are not hosts themselves but
+// supply defaults to the aliases they match, first value winning as in ssh.
+func LoadSSHProfiles() ([]SSHProfile, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	path := filepath.Join(home, ".ssh", "config")
+	profiles, err := ParseSSHConfig(path)
+	if os.IsNotExist(err) {
+		return []SSHProfile{}, nil
+	}
+	return profiles, err
+}
+
+// ParseSSHConfig parses an ssh_config file and returns a profile for each
+// concrete host alias it defines
+func ParseSSHConfig(path string) ([]SSHProfile, error) {
+	p := &sshConfigParser{dir: filepath.Dir(path)}
+	// Options before the first Host line apply to every host
+	p.blocks = append(p.blocks, &sshConfigBlock{patterns: []string{"*"}, options: map[string][]string{}})
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+
+	profiles := make([]SSHProfile, 0, len(p.aliases))
+	for _, alias := range p.aliases {
+		profiles = append(profiles, SSHProfile{Name: alias, Config: p.resolve(alias, "", 0)})
+	}
+	return profiles, nil
+}
+
+// parseFile reads one config file into the parser's blocks
+func (p *sshConfigParser) parseFile(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("ssh config includes nested too deeply at %s", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Included files continue the current block until they start their own
+	current := p.blocks[len(p.blocks)-1]
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, args := splitSSHConfigLine(scanner.Text())
+		if key == "" {
+			continue
+		}
+		switch key {
+		case "host":
+			current = &sshConfigBlock{patterns: args, options: map[string][]string{}}
+			p.blocks = append(p.blocks, current)
+			for _, pattern := range args {
+				if !strings.ContainsAny(pattern, "*?!") && !containsString(p.aliases, pattern) {
+					p.aliases = append(p.aliases, pattern)
+				}
+			}
+		case "match":
+			// Match conditions depend on the connection; the block is
+			// kept but never applies
+			current = &sshConfigBlock{options: map[string][]string{}}
+			p.blocks = append(p.blocks, current)
+		case "include":
+			for _, arg := range args {
+				if err := p.include(arg, depth); err != nil {
+					return err
+				}
+			}
+		default:
+			if _, ok := current.options[key]; !ok || key == "identityfile" {
+				current.options[key] = append(current.options[key], args...)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// include parses the files matching an Include argument. Relative paths
+// are resolved against ~/.ssh and missing files are ignored, as in ssh.
+func (p *sshConfigParser) include(arg string, depth int) error {
+	pattern, err := expandHome(arg)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(p.dir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid ssh config include %q: %w", arg, err)
+	}
+	sort.Strings(matches)
+	for _, match := range matches {
+		if info, err := os.Stat(match); err != nil || info.IsDir() {
+			continue
+		}
+		if err := p.parseFile(match, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSSHConfigLine splits a config line into a lower-case keyword and its
+// arguments. Both "Key value" and "Key=value" forms are accepted, and
+// arguments may be double-quoted.
+func splitSSHConfigLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	var args []string
+	var arg strings.Builder
+	inQuote, hasArg := false, false
+	for _, r := range rest {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			hasArg = true
+		case (r == ' ' || r == '\t') && !inQuote:
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, arg.String())
+	}
+	return key, args
+}
+
+// option returns the first value of an option for a host, or ""
+func (p *sshConfigParser) option(alias, key string) string {
+	for _, block := range p.blocks {
+		if values, ok := block.options[key]; ok && len(values) > 0 && matchHostPatterns(block.patterns, alias) {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// identityFiles returns every IdentityFile for a host; unlike other
+// options, ssh offers all of them
+func (p *sshConfigParser) identityFiles(alias string) []string {
+	var files []string
+	for _, block := range p.blocks {
+		if matchHostPatterns(block.patterns, alias) {
+			files = append(files, block.options["identityfile"]...)
+		}
+	}
+	return files
+}
+
+// resolve builds the connection settings for a host alias. A user from a
+// user@host jump spec overrides the configured one. Jump hosts are resolved
+// with their own settings.
+func (p *sshConfigParser) resolve(alias, user string, depth int) SSHConfig {
+	cfg := SSHConfig{Host: alias, User: user, UseAgent: true}
+	if hostname := p.option(alias, "hostname"); hostname != "" {
+		cfg.Host = strings.ReplaceAll(hostname, "%h", alias)
+	}
+	if cfg.User == "" {
+		cfg.User = p.option(alias, "user")
+	}
+	if port, err := strconv.Atoi(p.option(alias, "port")); err == nil {
+		cfg.Port = port
+	}
+	if file := p.option(alias, "userknownhostsfile"); file != "" && !strings.EqualFold(file, "none") {
+		cfg.KnownHostsFile = file
+	}
+	if strings.EqualFold(p.option(alias, "stricthostkeychecking"), "accept-new") {
+		cfg.AcceptNewHostKey = true
+	}
+	if strings.EqualFold(p.option(alias, "identitiesonly"), "yes") {
+		cfg.UseAgent = false
+	}
+
+	if user == "" {
+		user = cfg.User
+	}
+	if user == "" {
+		user = localUser()
+	}
+	for _, file := range p.identityFiles(alias) {
+		cfg.IdentityFiles = append(cfg.IdentityFiles, expandSSHTokens(file, cfg.Host, user))
+	}
+
+	if jump := p.option(alias, "proxyjump"); jump != "" && !strings.EqualFold(jump, "none") {
+		for _, hop := range strings.Split(jump, ",") {
+			cfg.Jumps = append(cfg.Jumps, p.resolveJump(strings.TrimSpace(hop), depth)...)
+		}
+	}
+	return cfg
+}
+
+// resolveJump resolves a ProxyJump hop as ssh would connect to it,
+// preceded by the hosts the hop is itself reached through
+func (p *sshConfigParser) resolveJump(hop string, depth int) []SSHConfig {
+	user, host, port := parseJumpHost(hop)
+	if depth >= maxIncludeDepth {
+		return []SSHConfig{{Host: host, Port: port, User: user, UseAgent: true}}
+	}
+	jump := p.resolve(host, user, depth+1)
+	if port != 0 {
+		jump.Port = port
+	}
+	hops := jump.Jumps
+	jump.Jumps = nil
+	return append(hops, jump)
+}
+
+// expandSSHTokens expands the ~ and % tokens ssh allows in IdentityFile
+func expandSSHTokens(value, host, user string) string {
+	home, _ := os.UserHomeDir()
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		value = home + value[1:]
+	}
+	return strings.NewReplacer(
+		"%%", "%",
+		"%d", home,
+		"%h", host,
+		"%r", user,
+		"%u", localUser(),
+	).Replace(value)
+}
+
+// matchHostPatterns reports whether host matches a Host line. A matching
+// negated pattern excludes the host even if another pattern matches.
+func matchHostPatterns(patterns []string, host string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		if negated := strings.HasPrefix(pattern, "!"); negated {
+			if matchHostPattern(pattern[1:], host) {
+				return false
+			}
+		} else if matchHostPattern(pattern, host) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchHostPattern matches host against a pattern using ssh's * and ?
+// wildcards, case-insensitively
+func matchHostPattern(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(host); i >= 0; i-- {
+				if matchHostPattern(pattern[1:], host[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if host == "" {
+				return false
+			}
+		default:
+			if host == "" || host[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, host = pattern[1:], host[1:]
+	}
+	return host == ""
+}
+
+// containsString reports whether s is in list
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
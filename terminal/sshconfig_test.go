@@ -0,0 +1,187 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSSHConfig writes config files into a fresh ~/.ssh and returns the
+// path of the main config
+func writeSSHConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ssh")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "config")
+}
+
+// parseProfiles parses a config and indexes the profiles by alias
+func parseProfiles(t *testing.T, path string) map[string]SSHConfig {
+	t.Helper()
+	profiles, err := ParseSSHConfig(path)
+	if err != nil {
+		t.Fatalf("ParseSSHConfig: %v", err)
+	}
+	byName := map[string]SSHConfig{}
+	for _, p := range profiles {
+		byName[p.Name] = p.Config
+	}
+	return byName
+}
+
+func TestParseSSHConfigInclude(t *testing.T) {
+	path := writeSSHConfig(t, map[string]string{
+		"config":      "Include conf.d/*\n\nHost main\n    HostName main.example.com\n",
+		"conf.d/work": "Host work\n    HostName work.example.com\n    Port 2222\n",
+	})
+
+	profiles := parseProfiles(t, path)
+	if len(profiles) != 2 {
+		t.Fatalf("profiles = %v, want work and main", profiles)
+	}
+	if work := profiles["work"]; work.Host != "work.example.com" || work.Port != 2222 {
+		t.Errorf("work = %s:%d", work.Host, work.Port)
+	}
+	if main := profiles["main"]; main.Host != "main.example.com" {
+		t.Errorf("main host = %s", main.Host)
+	}
+}
+
+func TestParseSSHConfigWildcardDefaults(t *testing.T) {
+	path := writeSSHConfig(t, map[string]string{
+		"config": `Host db
+    User dba
+
+Host web
+    HostName web.example.com
+
+Host *.example.com web
+    Port 2200
+    IdentitiesOnly yes
+    StrictHostKeyChecking accept-new
+
+Host *
+    User everyone
+`,
+	})
+
+	profiles := parseProfiles(t, path)
+	if _, ok := profiles["*.example.com"]; ok {
+		t.Error("wildcard pattern listed as a host")
+	}
+	db, web := profiles["db"], profiles["web"]
+	if db.User != "dba" || db.Port != 0 || !db.UseAgent {
+		t.Errorf("db = %+v", db)
+	}
+	if web.User != "everyone" || web.Port != 2200 || web.UseAgent || !web.AcceptNewHostKey {
+		t.Errorf("web = %+v", web)
+	}
+}
+
+func TestParseSSHConfigFirstValueWins(t *testing.T) {
+	path := writeSSHConfig(t, map[string]string{
+		"config": `Host app
+    User first
+    Port 2201
+    IdentityFile ~/.ssh/one
+
+Host app
+    User second
+    Port 2202
+    IdentityFile ~/.ssh/two
+
+Host *
+    User fallback
+`,
+	})
+
+	home, _ := os.UserHomeDir()
+	app := parseProfiles(t, path)["app"]
+	if app.User != "first" || app.Port != 2201 {
+		t.Errorf("app = %s port %d, want first port 2201", app.User, app.Port)
+	}
+	// Every IdentityFile is offered, in order
+	want := []string{filepath.Join(home, ".ssh/one"), filepath.Join(home, ".ssh/two")}
+	if !reflect.DeepEqual(app.IdentityFiles, want) {
+		t.Errorf("identity files = %v, want %v", app.IdentityFiles, want)
+	}
+}
+
+func TestParseSSHConfigTokens(t *testing.T) {
+	path := writeSSHConfig(t, map[string]string{
+		"config": `Host box
+    HostName %h.internal
+    User deploy
+    IdentityFile ~/.ssh/%r@%h
+`,
+	})
+
+	home, _ := os.UserHomeDir()
+	box := parseProfiles(t, path)["box"]
+	if box.Host != "box.internal" {
+		t.Errorf("host = %s, want box.internal", box.Host)
+	}
+	want := []string{filepath.Join(home, ".ssh", "deploy@box.internal")}
+	if !reflect.DeepEqual(box.IdentityFiles, want) {
+		t.Errorf("identity files = %v, want %v", box.IdentityFiles, want)
+	}
+}
+
+func TestParseSSHConfigNestedProxyJump(t *testing.T) {
+	path := writeSSHConfig(t, map[string]string{
+		"config": `Host target
+    HostName 10.0.0.5
+    ProxyJump inner
+
+Host inner
+    HostName inner.example.com
+    User jumper
+    IdentityFile ~/.ssh/inner_key
+    UserKnownHostsFile ~/.ssh/inner_hosts
+    ProxyJump admin@outer:2222
+
+Host outer
+    HostName outer.example.com
+    User ignored
+    IdentityFile ~/.ssh/%r_outer
+    IdentitiesOnly yes
+    StrictHostKeyChecking accept-new
+`,
+	})
+
+	home, _ := os.UserHomeDir()
+	target := parseProfiles(t, path)["target"]
+	want := []SSHConfig{
+		{
+			Host:             "outer.example.com",
+			Port:             2222,
+			User:             "admin",
+			IdentityFiles:    []string{filepath.Join(home, ".ssh", "admin_outer")},
+			AcceptNewHostKey: true,
+		},
+		{
+			Host:           "inner.example.com",
+			User:           "jumper",
+			IdentityFiles:  []string{filepath.Join(home, ".ssh", "inner_key")},
+			UseAgent:       true,
+			KnownHostsFile: "~/.ssh/inner_hosts",
+		},
+	}
+	if !reflect.DeepEqual(target.Jumps, want) {
+		t.Errorf("jumps = %+v\nwant %+v", target.Jumps, want)
+	}
+	if target.Host != "10.0.0.5" || len(target.IdentityFiles) != 0 {
+		t.Errorf("target = %+v", target)
+	}
+}
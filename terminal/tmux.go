@@ -0,0 +1,683 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tmuxCommandTimeout bounds how long a control-mode command may take
+	tmuxCommandTimeout = 10 * time.Second
+	// tmuxSendChunk is the most input bytes sent in one send-keys command
+	tmuxSendChunk = 512
+	// tmuxDCSStart and tmuxDCSEnd wrap the protocol when tmux runs with -CC
+	tmuxDCSStart = "\x1bP1000p"
+	tmuxDCSEnd   = "\x1b\\"
+	// tmuxPaneFormat describes a pane in list-panes output
+	tmuxPaneFormat = "#{window_id} #{pane_id} #{pane_width} #{pane_height} #{window_name}"
+)
+
+// TmuxSessionInfo describes a session on the tmux server
+type TmuxSessionInfo struct {
+	Name     string `json:"name"`
+	Windows  int    `json:"windows"`
+	Attached bool   `json:"attached"`
+}
+
+// ListTmuxSessions returns the sessions on the default tmux server
+func ListTmuxSessions() ([]TmuxSessionInfo, error) {
+	out, err := exec.Command("tmux", "list-sessions", "-F", "#{session_attached} #{session_windows} #{session_name}").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// tmux exits with an error when no server is running
+			return []TmuxSessionInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to list tmux sessions: %w", err)
+	}
+
+	sessions := []TmuxSessionInfo{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		windows, _ := strconv.Atoi(fields[1])
+		sessions = append(sessions, TmuxSessionInfo{
+			Name:     fields[2],
+			Windows:  windows,
+			Attached: fields[0] != "0",
+		})
+	}
+	return sessions, nil
+}
+
+// tmuxReply is the output of one control-mode command
+type tmuxReply struct {
+	lines []string
+	err   error
+}
+
+// tmuxCall is a command waiting for its reply. tmux answers commands in
+// order, so a call whose caller gave up stays queued as stale and its
+// reply is dropped when it arrives.
+type tmuxCall struct {
+	reply chan tmuxReply
+	stale bool
+}
+
+// TmuxClient is a tmux control-mode client. Each pane of the attached tmux
+// session is exposed as its own Backend, so panes open as native sessions
+// while their processes keep running in tmux when the app goes away.
+type TmuxClient struct {
+	target string
+	pty    *os.File
+	cmd    *exec.Cmd
+	// adopt is called for every pane that appears
+	adopt func(*TmuxPane)
+	// tasks run commands on behalf of notifications, which the reader
+	// cannot do itself without waiting on its own replies
+	tasks chan func()
+
+	// writeMu keeps commands in the same order as their pending replies
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending []*tmuxCall
+	panes   map[string]*TmuxPane
+	closed  bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// startTmuxClient attaches to a tmux session in control mode, creating it
+// if it does not exist, and adopts its existing panes
+func startTmuxClient(target string, adopt func(*TmuxPane)) (*TmuxClient, error) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return nil, fmt.Errorf("tmux is not installed: %w", err)
+	}
+	if target == "" {
+		target = "main"
+	}
+
+	cmd := exec.Command("tmux", "-CC", "new-session", "-A", "-s", target)
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	ptmx, err := startPollable(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tmux: %w", err)
+	}
+
+	c := &TmuxClient{
+		target: target,
+		pty:    ptmx,
+		cmd:    cmd,
+		adopt:  adopt,
+		tasks:  make(chan func(), 64),
+		panes:  make(map[string]*TmuxPane),
+		done:   make(chan struct{}),
+	}
+
+	// tmux answers the command it was started with before anything else
+	first := &tmuxCall{reply: make(chan tmuxReply, 1)}
+	c.pending = append(c.pending, first)
+
+	go c.readLoop(ptmx)
+	go c.taskLoop()
+
+	select {
+	case reply := <-first.reply:
+		if reply.err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to attach to tmux session %s: %w", target, reply.err)
+		}
+	case <-c.done:
+		return nil, fmt.Errorf("tmux exited before attaching to %s", target)
+	case <-time.After(tmuxCommandTimeout):
+		c.Close()
+		return nil, fmt.Errorf("timed out attaching to tmux session %s", target)
+	}
+
+	if err := c.syncPanes("-s"); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Target returns the name of the attached tmux session
+func (c *TmuxClient) Target() string {
+	return c.target
+}
+
+// Done returns a channel that is closed once the control client has exited
+func (c *TmuxClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Command runs a tmux command and returns its output lines
+func (c *TmuxClient) Command(command string) ([]string, error) {
+	call := &tmuxCall{reply: make(chan tmuxReply, 1)}
+
+	c.writeMu.Lock()
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		c.writeMu.Unlock()
+		return nil, errors.New("tmux client has exited")
+	}
+	c.pending = append(c.pending, call)
+	c.mu.Unlock()
+	_, err := io.WriteString(c.pty, command+"\n")
+	if err != nil {
+		// tmux will not answer a line it never received. A partial line is
+		// completed by the next command and answered once for both.
+		c.mu.Lock()
+		if n := len(c.pending); n > 0 && c.pending[n-1] == call {
+			c.pending = c.pending[:n-1]
+		}
+		c.mu.Unlock()
+	}
+	c.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send tmux command: %w", err)
+	}
+
+	select {
+	case r := <-call.reply:
+		return r.lines, r.err
+	case <-c.done:
+		return nil, errors.New("tmux client has exited")
+	case <-time.After(tmuxCommandTimeout):
+		c.mu.Lock()
+		call.stale = true
+		c.mu.Unlock()
+		return nil, fmt.Errorf("tmux command timed out: %s", command)
+	}
+}
+
+// readLoop parses control-mode lines from r until tmux exits
+func (c *TmuxClient) readLoop(r io.Reader) {
+	defer c.shutdown()
+
+	reader := bufio.NewReader(r)
+	var block []string
+	inBlock := false
+	for {
+		raw, err := reader.ReadString('\n')
+		if raw != "" {
+			line := strings.TrimRight(raw, "\r\n")
+			line = strings.TrimPrefix(line, tmuxDCSStart)
+			line = strings.TrimPrefix(line, tmuxDCSEnd)
+
+			switch {
+			case inBlock && (strings.HasPrefix(line, "%end ") || strings.HasPrefix(line, "%error ")):
+				var replyErr error
+				if strings.HasPrefix(line, "%error ") {
+					replyErr = fmt.Errorf("tmux: %s", strings.Join(block, "; "))
+				}
+				c.deliver(tmuxReply{lines: block, err: replyErr})
+				block, inBlock = nil, false
+			case inBlock:
+				block = append(block, line)
+			case strings.HasPrefix(line, "%begin "):
+				inBlock = true
+			case strings.HasPrefix(line, "%exit"):
+				return
+			case strings.HasPrefix(line, "%"):
+				c.notify(line)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// deliver hands a reply to the oldest waiting command, dropping it if
+// that command timed out
+func (c *TmuxClient) deliver(reply tmuxReply) {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	call := c.pending[0]
+	c.pending = c.pending[1:]
+	stale := call.stale
+	c.mu.Unlock()
+	if !stale {
+		call.reply <- reply
+	}
+}
+
+// notify handles an asynchronous notification line
+func (c *TmuxClient) notify(line string) {
+	name, rest, _ := strings.Cut(line, " ")
+	switch name {
+	case "%output":
+		pane, data, _ := strings.Cut(rest, " ")
+		c.output(pane, data)
+	case "%extended-output":
+		// %extended-output %pane age ... : data
+		pane, tail, _ := strings.Cut(rest, " ")
+		if _, data, ok := strings.Cut(tail, " : "); ok {
+			c.output(pane, data)
+		}
+	case "%window-add", "%layout-change":
+		window, _, _ := strings.Cut(rest, " ")
+		c.schedule(func() { c.syncPanes("-t " + window) })
+	case "%window-close", "%unlinked-window-close":
+		window, _, _ := strings.Cut(rest, " ")
+		c.closeWindow(window)
+	case "%window-renamed":
+		window, title, _ := strings.Cut(rest, " ")
+		c.renameWindow(window, title)
+	case "%sessions-changed", "%session-changed":
+		// A pane may have moved between windows or sessions
+		c.schedule(func() { c.syncPanes("-s") })
+	}
+}
+
+// schedule runs fn on the task goroutine. The reader must never block
+// here, since the task may be waiting for a reply only the reader delivers.
+func (c *TmuxClient) schedule(fn func()) {
+	select {
+	case c.tasks <- fn:
+	default:
+		go func() {
+			select {
+			case c.tasks <- fn:
+			case <-c.done:
+			}
+		}()
+	}
+}
+
+// taskLoop runs scheduled tasks one at a time until the client exits
+func (c *TmuxClient) taskLoop() {
+	for {
+		select {
+		case fn := <-c.tasks:
+			fn()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// output queues decoded pane output for its session
+func (c *TmuxClient) output(paneID, data string) {
+	c.mu.Lock()
+	pane := c.panes[paneID]
+	c.mu.Unlock()
+	if pane != nil {
+		pane.push(unescapeTmuxOutput(data))
+	}
+}
+
+// syncPanes lists the panes matched by scope, adopting new ones and ending
+// panes of the listed windows that no longer exist
+func (c *TmuxClient) syncPanes(scope string) error {
+	lines, err := c.Command("list-panes " + scope + " -F '" + tmuxPaneFormat + "'")
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	windows := make(map[string]bool)
+	var added []*TmuxPane
+	c.mu.Lock()
+	for _, line := range lines {
+		fields := strings.SplitN(line, " ", 5)
+		if len(fields) < 4 {
+			continue
+		}
+		window, id := fields[0], fields[1]
+		cols, _ := strconv.Atoi(fields[2])
+		rows, _ := strconv.Atoi(fields[3])
+		title := ""
+		if len(fields) == 5 {
+			title = fields[4]
+		}
+		seen[id], windows[window] = true, true
+
+		if pane, ok := c.panes[id]; ok {
+			pane.setWindow(window, title)
+			continue
+		}
+		pane := newTmuxPane(c, id, window, title, rows, cols)
+		c.panes[id] = pane
+		added = append(added, pane)
+	}
+	var gone []*TmuxPane
+	for id, pane := range c.panes {
+		if !seen[id] && (scope == "-s" || windows[pane.windowID()]) {
+			gone = append(gone, pane)
+			delete(c.panes, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, pane := range gone {
+		pane.end()
+	}
+	for _, pane := range added {
+		// Show what the pane already displays before its live output
+		pane.prime(c.capturePane(pane.id))
+		c.adopt(pane)
+	}
+	return nil
+}
+
+// capturePane returns a pane's visible contents with the cursor moved to
+// where tmux has it, or nil if the pane cannot be captured
+func (c *TmuxClient) capturePane(id string) []byte {
+	screen, err := c.Command("capture-pane -p -e -t " + id)
+	if err != nil {
+		return nil
+	}
+	out := []byte(strings.Join(screen, "\r\n"))
+
+	lines, err := c.Command("display -p -t " + id + " '#{cursor_x},#{cursor_y}'")
+	if err != nil || len(lines) == 0 {
+		return out
+	}
+	x, y, _ := strings.Cut(lines[0], ",")
+	col, errX := strconv.Atoi(x)
+	row, errY := strconv.Atoi(y)
+	if errX == nil && errY == nil {
+		out = fmt.Appendf(out, "\x1b[%d;%dH", row+1, col+1)
+	}
+	return out
+}
+
+// closeWindow ends the panes of a closed window
+func (c *TmuxClient) closeWindow(window string) {
+	var gone []*TmuxPane
+	c.mu.Lock()
+	for id, pane := range c.panes {
+		if pane.windowID() == window {
+			gone = append(gone, pane)
+			delete(c.panes, id)
+		}
+	}
+	c.mu.Unlock()
+	for _, pane := range gone {
+		pane.end()
+	}
+}
+
+// renameWindow updates the title of a window's panes
+func (c *TmuxClient) renameWindow(window, title string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pane := range c.panes {
+		if pane.windowID() == window {
+			pane.setWindow(window, title)
+		}
+	}
+}
+
+// largestPane returns the largest size any pane of a window is shown at,
+// or of any pane at all if window is empty
+func (c *TmuxClient) largestPane(window string) (rows, cols int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pane := range c.panes {
+		if window != "" && pane.windowID() != window {
+			continue
+		}
+		r, cl := pane.Size()
+		rows, cols = max(rows, r), max(cols, cl)
+	}
+	return rows, cols
+}
+
+// forget stops routing output to a pane that was closed locally
+func (c *TmuxClient) forget(id string) {
+	c.mu.Lock()
+	delete(c.panes, id)
+	c.mu.Unlock()
+}
+
+// Detach leaves the tmux session running and ends the control client. The
+// client's panes end as sessions but keep running inside tmux.
+func (c *TmuxClient) Detach() {
+	c.Command("detach-client")
+	c.Close()
+}
+
+// Close ends the control client without detaching cleanly
+func (c *TmuxClient) Close() error {
+	c.pty.Close()
+	<-c.done
+	c.cmd.Wait()
+	return nil
+}
+
+// shutdown ends every pane and fails outstanding commands once tmux exits
+func (c *TmuxClient) shutdown() {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closed = true
+		panes := c.panes
+		c.panes = make(map[string]*TmuxPane)
+		pending := c.pending
+		c.pending = nil
+		c.mu.Unlock()
+
+		close(c.done)
+		for _, call := range pending {
+			call.reply <- tmuxReply{err: errors.New("tmux client has exited")}
+		}
+		for _, pane := range panes {
+			pane.end()
+		}
+	})
+}
+
+// unescapeTmuxOutput decodes %output data, in which tmux escapes
+// backslashes and bytes below 0x20 as \ooo octal sequences
+func unescapeTmuxOutput(data string) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' && i+3 < len(data) {
+			if n, err := strconv.ParseUint(data[i+1:i+4], 8, 8); err == nil {
+				out = append(out, byte(n))
+				i += 3
+				continue
+			}
+		}
+		out = append(out, data[i])
+	}
+	return out
+}
+
+// TmuxPane is a Backend for one pane of a tmux session
+type TmuxPane struct {
+	client *TmuxClient
+	id     string
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	window string
+	title  string
+	rows   int
+	cols   int
+	primed bool
+	held   []byte // output received before the initial capture
+	out    []byte
+	closed bool
+}
+
+// newTmuxPane creates a pane that buffers output until it is primed
+func newTmuxPane(client *TmuxClient, id, window, title string, rows, cols int) *TmuxPane {
+	p := &TmuxPane{client: client, id: id, window: window, title: title, rows: rows, cols: cols}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// prime sets the pane's initial screen contents, followed by any output
+// that arrived while they were captured
+func (p *TmuxPane) prime(screen []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.out = append(append(screen, p.held...), p.out...)
+	p.held = nil
+	p.primed = true
+	p.cond.Broadcast()
+}
+
+// push queues output for the session reader
+func (p *TmuxPane) push(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	if !p.primed {
+		p.held = append(p.held, data...)
+		return
+	}
+	p.out = append(p.out, data...)
+	p.cond.Broadcast()
+}
+
+// end marks the pane as gone, which ends its session once output is read
+func (p *TmuxPane) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.primed = true
+	p.cond.Broadcast()
+}
+
+// windowID returns the ID of the window holding the pane
+func (p *TmuxPane) windowID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.window
+}
+
+// setWindow records the pane's window and its name
+func (p *TmuxPane) setWindow(window, title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.window, p.title = window, title
+}
+
+// ID returns the tmux pane ID, e.g. "%3"
+func (p *TmuxPane) ID() string {
+	return p.id
+}
+
+// Size returns the pane size when it was discovered
+func (p *TmuxPane) Size() (rows, cols int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rows, p.cols
+}
+
+// Read blocks until the pane produces output
+func (p *TmuxPane) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for (len(p.out) == 0 || !p.primed) && !p.closed {
+		p.cond.Wait()
+	}
+	if len(p.out) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, p.out)
+	p.out = p.out[n:]
+	return n, nil
+}
+
+// Write types input into the pane. Keys are sent as hex so no byte needs
+// quoting for the tmux command parser.
+func (p *TmuxPane) Write(data []byte) (int, error) {
+	for start := 0; start < len(data); start += tmuxSendChunk {
+		end := start + tmuxSendChunk
+		if end > len(data) {
+			end = len(data)
+		}
+		var b strings.Builder
+		b.WriteString("send-keys -t " + p.id + " -H")
+		for _, c := range data[start:end] {
+			fmt.Fprintf(&b, " %02x", c)
+		}
+		if _, err := p.client.Command(b.String()); err != nil {
+			return start, err
+		}
+	}
+	return len(data), nil
+}
+
+// Resize records the size the pane is shown at and sizes its window to
+// fit the largest of the window's panes. Panes of one window share its
+// size, so sizing the window to each pane in turn would have them fight.
+func (p *TmuxPane) Resize(rows, cols int) error {
+	p.mu.Lock()
+	p.rows, p.cols = rows, cols
+	window := p.window
+	p.mu.Unlock()
+
+	rows, cols = p.client.largestPane(window)
+	_, err := p.client.Command(fmt.Sprintf("refresh-client -C %s:%dx%d", window, cols, rows))
+	if err == nil {
+		return nil
+	}
+	// tmux before 3.2 only sizes the client as a whole, which every window
+	// then follows
+	rows, cols = p.client.largestPane("")
+	_, err = p.client.Command(fmt.Sprintf("refresh-client -C %d,%d", cols, rows))
+	return err
+}
+
+// Close stops showing the pane. The pane keeps running inside tmux and
+// returns when the session is attached again.
+func (p *TmuxPane) Close() error {
+	p.client.forget(p.id)
+	p.end()
+	return nil
+}
+
+// Kill closes the pane inside tmux, ending the programs running in it
+func (p *TmuxPane) Kill() error {
+	_, err := p.client.Command("kill-pane -t " + p.id)
+	return err
+}
+
+// Wait blocks until the pane is closed or its window goes away
+func (p *TmuxPane) Wait() (ExitStatus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for !p.closed {
+		p.cond.Wait()
+	}
+	return ExitStatus{}, nil
+}
+
+// GetShell describes the pane as session:window name
+func (p *TmuxPane) GetShell() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return "tmux " + p.client.target + ":" + p.title
+}
+
+// Kind identifies TmuxPane as the tmux backend
+func (p *TmuxPane) Kind() string {
+	return "tmux"
+}
@@ -0,0 +1,222 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTmuxClient is a control client wired to pipes instead of tmux: the
+// test reads the commands it sends and writes the replies tmux would give
+type testTmuxClient struct {
+	*TmuxClient
+	commands *bufio.Reader
+	replies  *io.PipeWriter
+}
+
+func newTestTmuxClient(t *testing.T) *testTmuxClient {
+	t.Helper()
+	cmdR, cmdW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	replyR, replyW := io.Pipe()
+	c := &TmuxClient{
+		pty:   cmdW,
+		adopt: func(*TmuxPane) {},
+		tasks: make(chan func(), 64),
+		panes: make(map[string]*TmuxPane),
+		done:  make(chan struct{}),
+	}
+	go c.readLoop(replyR)
+	t.Cleanup(func() {
+		replyW.Close()
+		<-c.done
+		cmdR.Close()
+		cmdW.Close()
+	})
+	return &testTmuxClient{TmuxClient: c, commands: bufio.NewReader(cmdR), replies: replyW}
+}
+
+// command runs a command in the background and returns the channel its
+// result arrives on once the test has read the command line
+func (tc *testTmuxClient) command(t *testing.T, command string) <-chan tmuxReply {
+	t.Helper()
+	result := make(chan tmuxReply, 1)
+	go func() {
+		lines, err := tc.Command(command)
+		result <- tmuxReply{lines: lines, err: err}
+	}()
+	line, err := tc.commands.ReadString('\n')
+	if err != nil || line != command+"\n" {
+		t.Fatalf("sent %q, %v; want %q", line, err, command)
+	}
+	return result
+}
+
+// reply writes control-mode lines as tmux would
+func (tc *testTmuxClient) reply(t *testing.T, lines ...string) {
+	t.Helper()
+	if _, err := io.WriteString(tc.replies, strings.Join(lines, "\n")+"\n"); err != nil {
+		t.Fatalf("write reply: %v", err)
+	}
+}
+
+func waitReply(t *testing.T, result <-chan tmuxReply) tmuxReply {
+	t.Helper()
+	select {
+	case r := <-result:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a reply")
+		return tmuxReply{}
+	}
+}
+
+func TestUnescapeTmuxOutput(t *testing.T) {
+	tests := map[string]string{
+		`plain`:             "plain",
+		`a\015\012b`:        "a\r\nb",
+		`\033[1mbold\033[m`: "\x1b[1mbold\x1b[m",
+		`back\134slash`:     `back\slash`,
+		`tail\01`:           `tail\01`,
+		`\999`:              `\999`,
+	}
+	for in, want := range tests {
+		if got := string(unescapeTmuxOutput(in)); got != want {
+			t.Errorf("unescapeTmuxOutput(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTmuxClientReplies(t *testing.T) {
+	tc := newTestTmuxClient(t)
+
+	first := tc.command(t, "list-windows")
+	second := tc.command(t, "bogus")
+	// Notifications may arrive between replies, and in -CC mode the first
+	// line carries the DCS introducer
+	tc.reply(t,
+		tmuxDCSStart+"%begin 1 1 0",
+		"@1 one",
+		"@2 two",
+		"%end 1 1 0",
+		"%window-renamed @1 renamed",
+		"%begin 1 2 0",
+		"unknown command: bogus",
+		"%error 1 2 0",
+	)
+
+	r := waitReply(t, first)
+	if r.err != nil || !reflect.DeepEqual(r.lines, []string{"@1 one", "@2 two"}) {
+		t.Errorf("first reply = %q, %v", r.lines, r.err)
+	}
+	r = waitReply(t, second)
+	if r.err == nil || !strings.Contains(r.err.Error(), "unknown command: bogus") {
+		t.Errorf("second reply error = %v", r.err)
+	}
+}
+
+func TestTmuxClientDropsStaleReplies(t *testing.T) {
+	tc := newTestTmuxClient(t)
+
+	// A command that timed out is still answered by tmux later
+	abandoned := &tmuxCall{reply: make(chan tmuxReply, 1), stale: true}
+	tc.mu.Lock()
+	tc.pending = append(tc.pending, abandoned)
+	tc.mu.Unlock()
+
+	result := tc.command(t, "display -p ok")
+	tc.reply(t, "%begin 1 1 0", "late", "%end 1 1 0", "%begin 1 2 0", "ok", "%end 1 2 0")
+
+	if r := waitReply(t, result); r.err != nil || !reflect.DeepEqual(r.lines, []string{"ok"}) {
+		t.Errorf("reply = %q, %v; want [ok]", r.lines, r.err)
+	}
+	if len(abandoned.reply) != 0 {
+		t.Error("stale call was handed a reply")
+	}
+}
+
+func TestTmuxClientWriteFailure(t *testing.T) {
+	tc := newTestTmuxClient(t)
+	tc.pty.Close()
+
+	if _, err := tc.Command("list-windows"); err == nil {
+		t.Fatal("Command succeeded without a connection")
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if len(tc.pending) != 0 {
+		t.Errorf("%d calls left waiting for replies that will never come", len(tc.pending))
+	}
+}
+
+func TestTmuxClientPaneOutput(t *testing.T) {
+	tc := newTestTmuxClient(t)
+
+	pane := newTmuxPane(tc.TmuxClient, "%1", "@1", "one", 24, 80)
+	tc.mu.Lock()
+	tc.panes["%1"] = pane
+	tc.mu.Unlock()
+
+	// Output before the initial capture is held until the pane is primed
+	tc.reply(t, `%output %1 live\015\012`, `%output %2 other`)
+	time.Sleep(50 * time.Millisecond)
+	pane.prime([]byte("screen\r\n"))
+	tc.reply(t, `%extended-output %1 0 : more`)
+
+	want := "screen\r\nlive\r\nmore"
+	var got []byte
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got) < len(want) && time.Now().Before(deadline) {
+		n, err := pane.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if string(got) != want {
+		t.Errorf("pane output = %q, want %q", got, want)
+	}
+}
+
+func TestTmuxClientPrimesNewPanes(t *testing.T) {
+	tc := newTestTmuxClient(t)
+	adopted := make(chan *TmuxPane, 1)
+	tc.adopt = func(p *TmuxPane) { adopted <- p }
+
+	done := make(chan error, 1)
+	go func() { done <- tc.syncPanes("-s") }()
+
+	expect := func(prefix string, reply ...string) {
+		t.Helper()
+		line, err := tc.commands.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, prefix) {
+			t.Fatalf("sent %q, %v; want %s...", line, err, prefix)
+		}
+		tc.reply(t, append(append([]string{"%begin 1 1 0"}, reply...), "%end 1 1 0")...)
+	}
+	expect("list-panes -s", "@1 %1 80 24 shell")
+	expect("capture-pane -p -e -t %1", "$ ls", "a  b", "$")
+	expect("display -p -t %1", "2,2")
+
+	if err := <-done; err != nil {
+		t.Fatalf("syncPanes: %v", err)
+	}
+	pane := <-adopted
+	buf := make([]byte, 64)
+	n, err := pane.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got, want := string(buf[:n]), "$ ls\r\na  b\r\n$\x1b[3;3H"; got != want {
+		t.Errorf("primed screen = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package terminal
+
+import "errors"
+
+// TmuxSessionInfo describes a session on the tmux server
+type TmuxSessionInfo struct {
+	Name     string `json:"name"`
+	Windows  int    `json:"windows"`
+	Attached bool   `json:"attached"`
+}
+
+// ListTmuxSessions is not supported on Windows
+func ListTmuxSessions() ([]TmuxSessionInfo, error) {
+	return nil, errors.New("tmux is not supported on Windows")
+}
+
+// TmuxClient is a tmux control-mode client; tmux does not run on Windows
+type TmuxClient struct{}
+
+// TmuxPane is a Backend for one pane of a tmux session
+type TmuxPane struct{ Backend }
+
+// startTmuxClient is not supported on Windows
+func startTmuxClient(target string, adopt func(*TmuxPane)) (*TmuxClient, error) {
+	return nil, errors.New("tmux is not supported on Windows")
+}
+
+// Target returns the name of the attached tmux session
+func (c *TmuxClient) Target() string { return "" }
+
+// Done returns a closed channel since no client ever runs
+func (c *TmuxClient) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// Detach does nothing on Windows
+func (c *TmuxClient) Detach() {}
+
+// Size returns no size on Windows
+func (p *TmuxPane) Size() (rows, cols int) { return 0, 0 }
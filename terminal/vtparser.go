@@ -0,0 +1,276 @@
+package terminal
+
+import "unicode/utf8"
+
+const (
+	// maxCSIParams bounds the number of parameters collected for one sequence
+	maxCSIParams = 32
+	// maxOSCLength bounds the payload collected for one OSC sequence
+	maxOSCLength = 4096
+)
+
+// vtState is a state of the escape-sequence parser, following the DEC
+// ANSI-compatible parser model used by xterm
+type vtState int
+
+const (
+	vtGround vtState = iota
+	vtEscape
+	vtEscapeIntermediate
+	vtCSIEntry
+	vtCSIParam
+	vtCSIIntermediate
+	vtCSIIgnore
+	vtOSCString
+	vtOSCEscape
+	vtIgnoreString // DCS, SOS, PM and APC payloads are skipped
+	vtIgnoreStringEscape
+)
+
+// vtHandler receives the actions decoded by vtParser
+type vtHandler interface {
+	print(r rune)
+	execute(c byte)
+	csiDispatch(params []int, private byte, intermediates []byte, final byte)
+	escDispatch(intermediates []byte, final byte)
+	oscDispatch(data []byte)
+}
+
+// vtParser splits a byte stream into printable runes, control codes and
+// escape sequences. It is resumable: sequences may span calls to parse.
+type vtParser struct {
+	h     vtHandler
+	state vtState
+
+	params        []int
+	param         int
+	hasParam      bool
+	private       byte
+	intermediates []byte
+	osc           []byte
+
+	utf8Buf  [utf8.UTFMax]byte
+	utf8Len  int
+	utf8Need int
+}
+
+// newVTParser creates a parser that reports to h
+func newVTParser(h vtHandler) *vtParser {
+	return &vtParser{h: h}
+}
+
+// parse feeds a chunk of output through the state machine
+func (p *vtParser) parse(data []byte) {
+	for _, c := range data {
+		p.advance(c)
+	}
+}
+
+// advance processes a single byte
+func (p *vtParser) advance(c byte) {
+	// A multi-byte UTF-8 character in progress only continues in ground state
+	if p.utf8Need > 0 {
+		if c&0xc0 == 0x80 {
+			p.utf8Buf[p.utf8Len] = c
+			p.utf8Len++
+			if p.utf8Len == p.utf8Need {
+				r, _ := utf8.DecodeRune(p.utf8Buf[:p.utf8Len])
+				p.utf8Need, p.utf8Len = 0, 0
+				p.h.print(r)
+			}
+			return
+		}
+		p.utf8Need, p.utf8Len = 0, 0
+		p.h.print(utf8.RuneError)
+	}
+
+	// CAN and SUB abort any sequence; ESC starts a new one except inside strings
+	switch c {
+	case 0x18, 0x1a:
+		p.state = vtGround
+		return
+	case 0x1b:
+		switch p.state {
+		case vtOSCString:
+			p.state = vtOSCEscape
+		case vtIgnoreString:
+			p.state = vtIgnoreStringEscape
+		default:
+			p.clear()
+			p.state = vtEscape
+		}
+		return
+	}
+
+	switch p.state {
+	case vtGround:
+		p.ground(c)
+
+	case vtEscape:
+		switch {
+		case c < 0x20:
+			p.h.execute(c)
+		case c == '[':
+			p.clear()
+			p.state = vtCSIEntry
+		case c == ']':
+			p.osc = p.osc[:0]
+			p.state = vtOSCString
+		case c == 'P' || c == 'X' || c == '^' || c == '_':
+			p.state = vtIgnoreString
+		case c <= 0x2f:
+			p.intermediates = append(p.intermediates, c)
+			p.state = vtEscapeIntermediate
+		case c < 0x7f:
+			p.h.escDispatch(p.intermediates, c)
+			p.state = vtGround
+		}
+
+	case vtEscapeIntermediate:
+		switch {
+		case c < 0x20:
+			p.h.execute(c)
+		case c <= 0x2f:
+			p.intermediates = append(p.intermediates, c)
+		case c < 0x7f:
+			p.h.escDispatch(p.intermediates, c)
+			p.state = vtGround
+		}
+
+	case vtCSIEntry, vtCSIParam:
+		switch {
+		case c < 0x20:
+			p.h.execute(c)
+		case c >= '0' && c <= '9':
+			p.param = p.param*10 + int(c-'0')
+			if p.param > 65535 {
+				p.param = 65535
+			}
+			p.hasParam = true
+			p.state = vtCSIParam
+		case c == ';' || c == ':':
+			p.pushParam()
+			p.state = vtCSIParam
+		case c >= '<' && c <= '?':
+			if p.state == vtCSIEntry {
+				p.private = c
+				p.state = vtCSIParam
+			} else {
+				p.state = vtCSIIgnore
+			}
+		case c <= 0x2f:
+			p.intermediates = append(p.intermediates, c)
+			p.state = vtCSIIntermediate
+		case c < 0x7f:
+			p.dispatchCSI(c)
+		}
+
+	case vtCSIIntermediate:
+		switch {
+		case c < 0x20:
+			p.h.execute(c)
+		case c <= 0x2f:
+			p.intermediates = append(p.intermediates, c)
+		case c <= 0x3f:
+			p.state = vtCSIIgnore
+		case c < 0x7f:
+			p.dispatchCSI(c)
+		}
+
+	case vtCSIIgnore:
+		switch {
+		case c < 0x20:
+			p.h.execute(c)
+		case c >= 0x40 && c < 0x7f:
+			p.state = vtGround
+		}
+
+	case vtOSCString:
+		switch {
+		case c == 0x07:
+			p.h.oscDispatch(p.osc)
+			p.state = vtGround
+		case c < 0x20:
+			// Other controls are ignored inside OSC
+		case len(p.osc) < maxOSCLength:
+			p.osc = append(p.osc, c)
+		}
+
+	case vtOSCEscape:
+		// ESC \ (ST) terminates the string; anything else aborts it
+		if c == '\\' {
+			p.h.oscDispatch(p.osc)
+			p.state = vtGround
+		} else {
+			p.clear()
+			p.state = vtEscape
+			p.advance(c)
+		}
+
+	case vtIgnoreString:
+		if c == 0x07 {
+			p.state = vtGround
+		}
+
+	case vtIgnoreStringEscape:
+		if c == '\\' {
+			p.state = vtGround
+		} else {
+			p.state = vtIgnoreString
+		}
+	}
+}
+
+// ground handles a byte outside any escape sequence
+func (p *vtParser) ground(c byte) {
+	switch {
+	case c < 0x20:
+		p.h.execute(c)
+	case c == 0x7f:
+		// DEL is ignored
+	case c < utf8.RuneSelf:
+		p.h.print(rune(c))
+	case c&0xe0 == 0xc0:
+		p.startUTF8(c, 2)
+	case c&0xf0 == 0xe0:
+		p.startUTF8(c, 3)
+	case c&0xf8 == 0xf0:
+		p.startUTF8(c, 4)
+	default:
+		p.h.print(utf8.RuneError)
+	}
+}
+
+// startUTF8 begins collecting a multi-byte character
+func (p *vtParser) startUTF8(c byte, need int) {
+	p.utf8Buf[0] = c
+	p.utf8Len = 1
+	p.utf8Need = need
+}
+
+// clear resets the sequence collected so far
+func (p *vtParser) clear() {
+	p.params = p.params[:0]
+	p.param = 0
+	p.hasParam = false
+	p.private = 0
+	p.intermediates = p.intermediates[:0]
+}
+
+// pushParam ends the current parameter. Omitted parameters are recorded as 0.
+func (p *vtParser) pushParam() {
+	if len(p.params) < maxCSIParams {
+		p.params = append(p.params, p.param)
+	}
+	p.param = 0
+	p.hasParam = false
+}
+
+// dispatchCSI completes a control sequence
+func (p *vtParser) dispatchCSI(final byte) {
+	if p.hasParam || len(p.params) > 0 {
+		p.pushParam()
+	}
+	p.h.csiDispatch(p.params, p.private, p.intermediates, final)
+	p.state = vtGround
+}
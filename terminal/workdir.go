@@ -0,0 +1,54 @@
+package terminal
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// WorkingDir describes the current directory of a session
+type WorkingDir struct {
+	Path string `json:"path"`
+	// Host is the machine named in an OSC 7 report, which differs from this
+	// one when the shell runs over SSH
+	Host string `json:"host,omitempty"`
+	// Source is "shell" when reported with OSC 7 or "process" when read from the OS
+	Source string `json:"source"`
+}
+
+// dirLookup is implemented by backends that can ask the OS for the working
+// directory of the shell or its foreground process
+type dirLookup interface {
+	WorkingDir() (string, error)
+}
+
+// parseDirReport parses the argument of an OSC 7 sequence, a file:// URL
+// (or kitty's kitty-shell-cwd:// variant) naming the host and directory
+func parseDirReport(arg string) (host, path string, ok bool) {
+	u, err := url.Parse(arg)
+	if err != nil || (u.Scheme != "file" && u.Scheme != "kitty-shell-cwd") || u.Path == "" {
+		return "", "", false
+	}
+	return u.Host, u.Path, true
+}
+
+// WorkingDir returns the session's current directory. A directory reported by
+// shell integration is preferred since it follows the shell across SSH hops;
+// otherwise the backend is asked for the foreground process's directory.
+func (s *Session) WorkingDir() (WorkingDir, error) {
+	if host, path, ok := s.screen.ReportedDir(); ok {
+		if hostname, err := os.Hostname(); err == nil && host == hostname {
+			host = ""
+		}
+		return WorkingDir{Path: path, Host: host, Source: "shell"}, nil
+	}
+
+	if lookup, ok := s.backend.(dirLookup); ok {
+		path, err := lookup.WorkingDir()
+		if err != nil {
+			return WorkingDir{}, err
+		}
+		return WorkingDir{Path: path, Source: "process"}, nil
+	}
+	return WorkingDir{}, fmt.Errorf("working directory of session %s is unknown", s.id)
+}